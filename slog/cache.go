@@ -11,12 +11,19 @@ import (
 
 type structCache struct {
 	sync.RWMutex
-	m map[reflect.Type]*structInfo
+	m map[structKey]*structInfo
+}
+
+// structKey identifies cached field lists: each view of a type has its own.
+type structKey struct {
+	typ  reflect.Type
+	view string
 }
 
 type structInfo struct {
 	hasLogTag bool
 	fields    []fieldInfo
+	views     map[string]bool // views declared by the type (slog.<view> tags and view= options)
 }
 
 type fieldInfo struct {
@@ -37,11 +44,26 @@ type fieldOptions struct {
 	Precision  int
 	Format     string
 	Unit       string
+	Class      string   // Data class (pii, phi, pci, secret) for ClassPolicy
+	Level      LogLevel // Highest encoding level at which the field is emitted (with HasLevel)
+	HasLevel   bool
+	View       string // Views the field is restricted to, separated by '|' (empty: all views)
 }
 
+// getStructInfo returns the field list of rt in the current view. Views the type
+// does not declare share the default entry, so arbitrary view names cannot grow
+// the cache.
 func (e *encoder) getStructInfo(rt reflect.Type) *structInfo {
+	info := e.cachedStructInfo(structKey{typ: rt})
+	if view := e.opts.View; view != "" && info.views[view] {
+		return e.cachedStructInfo(structKey{typ: rt, view: view})
+	}
+	return info
+}
+
+func (e *encoder) cachedStructInfo(key structKey) *structInfo {
 	fieldCache.RLock()
-	info, ok := fieldCache.m[rt]
+	info, ok := fieldCache.m[key]
 	fieldCache.RUnlock()
 
 	if ok {
@@ -52,49 +74,139 @@ func (e *encoder) getStructInfo(rt reflect.Type) *structInfo {
 	defer fieldCache.Unlock()
 
 	// Double-check
-	if info, ok := fieldCache.m[rt]; ok {
+	if info, ok := fieldCache.m[key]; ok {
 		return info
 	}
 
-	info = &structInfo{}
+	rt := key.typ
+	info = &structInfo{views: make(map[string]bool)}
+	var jsonFields []fieldInfo
 
 	// Analyze struct fields
 	for i := 0; i < rt.NumField(); i++ {
 		sf := rt.Field(i)
-
-		// Parse slog tag
-		if tagStr, ok := sf.Tag.Lookup("slog"); ok {
-			info.hasLogTag = true
-			opts := e.parseFieldOptions(tagStr, sf)
-			info.fields = append(info.fields, fieldInfo{
-				index: i,
-				name:  sf.Name,
-				opts:  opts,
-			})
-			continue
+		for _, k := range tagKeys(sf.Tag) {
+			if view, ok := strings.CutPrefix(k, "slog."); ok {
+				info.views[view] = true
+				info.hasLogTag = true
+			} else if view, ok := strings.CutPrefix(k, "log."); ok {
+				info.views[view] = true
+				info.hasLogTag = true
+			} else if k == "slog" || k == "log" {
+				info.hasLogTag = true
+			}
 		}
 
 		// Parse json tag (for fallback)
-		jsonTag := sf.Tag.Get("json")
-		if jsonTag != "" {
+		if jsonTag := sf.Tag.Get("json"); jsonTag != "" {
 			name := jsonTag
 			opts := tagOpts("")
 			if idx := strings.IndexByte(name, ','); idx >= 0 {
 				name = name[:idx]
 				opts = tagOpts(jsonTag[idx+1:])
 			}
-			info.fields = append(info.fields, fieldInfo{
+			jsonFields = append(jsonFields, fieldInfo{
 				index:    i,
 				jsonName: name,
 				jsonOpts: opts,
 			})
 		}
+
+		// Parse slog tag (the short "log" key is accepted as an alias).
+		// A view-qualified tag (slog.<view>) takes precedence in that view.
+		tagStr, viewTag, ok := lookupLogTag(sf.Tag, key.view)
+		if !ok {
+			continue
+		}
+		opts := e.parseFieldOptions(tagStr, sf)
+		for _, view := range strings.Split(opts.View, "|") {
+			if view = strings.TrimSpace(view); view != "" {
+				info.views[view] = true
+			}
+		}
+		if !viewTag && !inView(opts.View, key.view) {
+			continue
+		}
+		info.fields = append(info.fields, fieldInfo{
+			index: i,
+			name:  sf.Name,
+			opts:  opts,
+		})
+	}
+
+	// The json tags only apply to types without any slog tag; fields hidden in
+	// this view stay hidden rather than falling back to their json names
+	if !info.hasLogTag {
+		info.fields = jsonFields
 	}
 
-	fieldCache.m[rt] = info
+	fieldCache.m[key] = info
 	return info
 }
 
+// lookupLogTag returns the slog tag for a field in the given view. viewTag reports
+// whether a view-qualified tag (slog.<view> or log.<view>) was used.
+//
+// The short "log" key is the documented tag (see README); "slog" is kept for
+// existing code and wins when a field carries both.
+func lookupLogTag(tag reflect.StructTag, view string) (value string, viewTag bool, ok bool) {
+	if view != "" {
+		if value, ok = tag.Lookup("slog." + view); ok {
+			return value, true, true
+		}
+		if value, ok = tag.Lookup("log." + view); ok {
+			return value, true, true
+		}
+	}
+	if value, ok = tag.Lookup("slog"); ok {
+		return value, false, true
+	}
+	value, ok = tag.Lookup("log")
+	return value, false, ok
+}
+
+// tagKeys lists the keys of a conventional `key:"value" key2:"value2"` struct tag.
+func tagKeys(tag reflect.StructTag) []string {
+	var keys []string
+	s := string(tag)
+	for s != "" {
+		s = strings.TrimLeft(s, " ")
+		i := strings.Index(s, ":\"")
+		if i <= 0 {
+			break
+		}
+		keys = append(keys, s[:i])
+		// Skip the quoted value
+		rest := s[i+2:]
+		s = ""
+		for j := 0; j < len(rest); j++ {
+			if rest[j] == '\\' {
+				j++
+				continue
+			}
+			if rest[j] == '"' {
+				s = rest[j+1:]
+				break
+			}
+		}
+	}
+	return keys
+}
+
+// inView reports whether a field restricted to views (e.g. "audit|ops") is visible in view.
+// Unrestricted fields are visible everywhere; restricted ones never in the default view.
+func inView(views, view string) bool {
+	if views == "" {
+		return true
+	}
+	for _, v := range strings.Split(views, "|") {
+		if strings.TrimSpace(v) == view && view != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *encoder) parseFieldOptions(tag string, sf reflect.StructField) fieldOptions {
 	opts := fieldOptions{
 		Name: sf.Name,
@@ -137,6 +249,18 @@ func (e *encoder) parseFieldOptions(tag string, sf reflect.StructField) fieldOpt
 			opts.Format = strings.TrimPrefix(seg, "format=")
 		case strings.HasPrefix(seg, "unit="):
 			opts.Unit = strings.TrimPrefix(seg, "unit=")
+		case strings.HasPrefix(seg, "level="):
+			// An unknown level hides the field at every level rather than always showing it
+			lvl, ok := ParseLogLevel(strings.TrimPrefix(seg, "level="))
+			if !ok {
+				lvl = DEBUG - 1
+			}
+			opts.Level = lvl
+			opts.HasLevel = true
+		case strings.HasPrefix(seg, "view="):
+			opts.View = strings.TrimPrefix(seg, "view=")
+		case strings.HasPrefix(seg, "class="):
+			opts.Class = parseClass(strings.TrimPrefix(seg, "class="))
 		}
 	}
 
@@ -0,0 +1,332 @@
+package slog
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// ----- CBOR (RFC 8949) -----
+
+// CBOR major types.
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborTag    = 6
+	cborSimple = 7
+)
+
+const (
+	cborFalse      = 0xf4
+	cborTrue       = 0xf5
+	cborNull       = 0xf6
+	cborUndefined  = 0xf7
+	cborFloat16    = 0xf9
+	cborFloat32    = 0xfa
+	cborFloat64    = 0xfb
+	cborBreak      = 0xff
+	cborIndefinite = 31
+
+	// cborTagJSON marks a byte string holding embedded JSON (IANA tag 262).
+	cborTagJSON = 262
+)
+
+var errCBORTruncated = errors.New("log: cbor: unexpected end of data")
+
+type cborWriter struct {
+	buf []byte
+}
+
+// writeHead writes a major type with its argument in the shortest form.
+func (w *cborWriter) writeHead(major byte, n uint64) {
+	m := major << 5
+	switch {
+	case n < 24:
+		w.buf = append(w.buf, m|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, m|24, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, m|25), uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, m|26), uint32(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, m|27), n)
+	}
+}
+
+func (w *cborWriter) writeNil() { w.buf = append(w.buf, cborNull) }
+
+func (w *cborWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, cborTrue)
+		return
+	}
+	w.buf = append(w.buf, cborFalse)
+}
+
+func (w *cborWriter) writeInt(n int64) {
+	if n >= 0 {
+		w.writeHead(cborUint, uint64(n))
+		return
+	}
+	w.writeHead(cborNegInt, uint64(-1-n))
+}
+
+func (w *cborWriter) writeUint(n uint64) { w.writeHead(cborUint, n) }
+
+func (w *cborWriter) writeFloat32(f float32) {
+	w.buf = binary.BigEndian.AppendUint32(append(w.buf, cborFloat32), math.Float32bits(f))
+}
+
+func (w *cborWriter) writeFloat64(f float64) {
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, cborFloat64), math.Float64bits(f))
+}
+
+func (w *cborWriter) writeString(s string) {
+	w.writeHead(cborText, uint64(len(s)))
+	w.buf = append(w.buf, s...)
+}
+
+func (w *cborWriter) writeArrayHeader(n int) { w.writeHead(cborArray, uint64(n)) }
+
+func (w *cborWriter) writeMapHeader(n int) { w.writeHead(cborMap, uint64(n)) }
+
+func (w *cborWriter) writeRawJSON(b []byte) {
+	w.writeHead(cborTag, cborTagJSON)
+	w.writeHead(cborBytes, uint64(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+// DecodeCBOR decodes a CBOR data item into nil, bool, int64, uint64 (above
+// MaxInt64), float64, string, []byte, []any or map[string]any. Embedded JSON
+// (tag 262) is returned as json.RawMessage; other tags yield their content.
+func DecodeCBOR(data []byte) (any, error) {
+	d := &cborDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("log: cbor: %d trailing bytes", len(d.data)-d.pos)
+	}
+	return v, nil
+}
+
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) next(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errCBORTruncated
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// head reads an initial byte and its argument. indefinite is set for additional information 31.
+func (d *cborDecoder) head() (major, info byte, arg uint64, indefinite bool, err error) {
+	b, err := d.next(1)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	major, info = b[0]>>5, b[0]&0x1f
+	switch {
+	case info < 24:
+		arg = uint64(info)
+	case info == 24:
+		b, err = d.next(1)
+		if err == nil {
+			arg = uint64(b[0])
+		}
+	case info == 25:
+		b, err = d.next(2)
+		if err == nil {
+			arg = uint64(binary.BigEndian.Uint16(b))
+		}
+	case info == 26:
+		b, err = d.next(4)
+		if err == nil {
+			arg = uint64(binary.BigEndian.Uint32(b))
+		}
+	case info == 27:
+		b, err = d.next(8)
+		if err == nil {
+			arg = binary.BigEndian.Uint64(b)
+		}
+	case info == cborIndefinite && major >= cborBytes && major <= cborMap:
+		indefinite = true
+	case info == cborIndefinite && major == cborSimple:
+		return 0, 0, 0, false, errors.New("log: cbor: unexpected break")
+	default:
+		return 0, 0, 0, false, fmt.Errorf("log: cbor: invalid additional information %d", info)
+	}
+	return major, info, arg, indefinite, err
+}
+
+// atBreak consumes a break code if one follows.
+func (d *cborDecoder) atBreak() (bool, error) {
+	if d.pos >= len(d.data) {
+		return false, errCBORTruncated
+	}
+	if d.data[d.pos] == cborBreak {
+		d.pos++
+		return true, nil
+	}
+	return false, nil
+}
+
+func (d *cborDecoder) value(depth int) (any, error) {
+	if depth > maxDecodeDepth {
+		return nil, errors.New("log: cbor: nesting too deep")
+	}
+	major, info, arg, indefinite, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if arg > math.MaxInt64 {
+			return arg, nil
+		}
+		return int64(arg), nil
+	case cborNegInt:
+		if arg > math.MaxInt64 {
+			return nil, errors.New("log: cbor: negative integer overflows int64")
+		}
+		return -1 - int64(arg), nil
+	case cborBytes, cborText:
+		b, err := d.stringBytes(major, arg, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == cborBytes {
+			return b, nil
+		}
+		if !utf8.Valid(b) {
+			return nil, errors.New("log: cbor: invalid UTF-8 in text string")
+		}
+		return string(b), nil
+	case cborArray:
+		arr := make([]any, 0, min(arg, 1024))
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite {
+				if done, err := d.atBreak(); done || err != nil {
+					return arr, err
+				}
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case cborMap:
+		m := make(map[string]any, min(arg, 1024))
+		for i := uint64(0); indefinite || i < arg; i++ {
+			if indefinite {
+				if done, err := d.atBreak(); done || err != nil {
+					return m, err
+				}
+			}
+			k, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			v, err := d.value(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				key = fmt.Sprint(k)
+			}
+			m[key] = v
+		}
+		return m, nil
+	case cborTag:
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if b, ok := v.([]byte); ok && arg == cborTagJSON {
+			return json.RawMessage(b), nil
+		}
+		return v, nil
+	default: // cborSimple
+		switch info {
+		case cborFalse & 0x1f:
+			return false, nil
+		case cborTrue & 0x1f:
+			return true, nil
+		case cborNull & 0x1f, cborUndefined & 0x1f:
+			return nil, nil
+		case cborFloat16 & 0x1f:
+			return float16ToFloat64(uint16(arg)), nil
+		case cborFloat32 & 0x1f:
+			return float64(math.Float32frombits(uint32(arg))), nil
+		case cborFloat64 & 0x1f:
+			return math.Float64frombits(arg), nil
+		}
+		return nil, fmt.Errorf("log: cbor: unsupported simple value %d", arg)
+	}
+}
+
+// stringBytes reads a definite string or concatenates the chunks of an indefinite one.
+func (d *cborDecoder) stringBytes(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		return d.next(n)
+	}
+	var out []byte
+	for {
+		if done, err := d.atBreak(); done || err != nil {
+			return out, err
+		}
+		chunkMajor, _, chunkLen, chunkIndefinite, err := d.head()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkIndefinite {
+			return nil, errors.New("log: cbor: invalid chunk in indefinite-length string")
+		}
+		b, err := d.next(chunkLen)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, b...)
+	}
+}
+
+// float16ToFloat64 converts an IEEE 754 half-precision value.
+func float16ToFloat64(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
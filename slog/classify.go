@@ -0,0 +1,72 @@
+package slog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ----- Data Classification -----
+
+// Well-known data classes for the `class=` tag option.
+const (
+	ClassPII    = "pii"    // Personally identifiable information
+	ClassPHI    = "phi"    // Protected health information (HIPAA)
+	ClassPCI    = "pci"    // Payment card data (PCI DSS)
+	ClassSecret = "secret" // Credentials, tokens, keys
+)
+
+// Class policy actions. Any other action value is treated as a mask name
+// (e.g. "hmac", "sha256", "encrypt", "default").
+const (
+	ClassKeep = "keep" // Emit the value as-is, ignoring field masks
+	ClassDrop = "drop" // Never serialize the field
+)
+
+// ClassPolicy maps a data class to the action applied to fields of that class.
+// Classes without an entry are processed according to their tags only.
+//
+// Example: one struct, two sinks
+//
+//	general := ClassPolicy{ClassSecret: ClassDrop, ClassPII: "hmac", ClassPCI: "default"}
+//	audit   := ClassPolicy{ClassSecret: ClassDrop, ClassPII: ClassKeep, ClassPCI: ClassKeep}
+type ClassPolicy map[string]string
+
+// classAction returns the policy action for a field class, or "" if none applies.
+func (e *encoder) classAction(class string) string {
+	if class == "" || e.opts.ClassPolicy == nil {
+		return ""
+	}
+	return e.opts.ClassPolicy[class]
+}
+
+// classMasked reports whether the field's class policy replaces its value with a mask.
+func (e *encoder) classMasked(fi fieldInfo) bool {
+	action := e.classAction(fi.opts.Class)
+	return action != "" && action != ClassKeep && action != ClassDrop
+}
+
+// maskClassValue applies a policy mask to an encoded value. Scalars are masked
+// in their string form; composite values are omitted so they cannot leak.
+func (e *encoder) maskClassValue(action string, val any) any {
+	if s, ok := scalarText(val); ok {
+		return e.getMask(action)(s)
+	}
+	return nil
+}
+
+// scalarText returns the string form of an encoded string, bool or number.
+func scalarText(val any) (string, bool) {
+	switch v := val.(type) {
+	case string:
+		return v, true
+	case bool, float32, float64, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%v", v), true
+	}
+	return "", false
+}
+
+// parseClass normalizes a class tag value.
+func parseClass(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
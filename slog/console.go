@@ -0,0 +1,215 @@
+package slog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ----- Console Output -----
+
+// ANSI escape sequences used by the console format.
+const (
+	ansiReset = "\x1b[0m"
+	ansiFaint = "\x1b[90m"
+	ansiKey   = "\x1b[36m"
+)
+
+var levelColors = map[LogLevel]string{
+	DEBUG: "\x1b[35m",
+	INFO:  "\x1b[32m",
+	WARN:  "\x1b[33m",
+	ERROR: "\x1b[31m",
+	FATAL: "\x1b[1;31m",
+}
+
+// consoleIndent prefixes the lines of pretty-printed nested values.
+const consoleIndent = "    "
+
+// DefaultConsoleTimeFormat is the time layout of console lines.
+const DefaultConsoleTimeFormat = "15:04:05.000"
+
+// ConsoleWriter writes human-readable records for development:
+//
+//	15:04:05.000 INFO  user created id=42 name="Ann Lee"
+//	    address: {
+//	      "city": "Paris"
+//	    }
+//
+// Records go through the same tag processing as Marshal, so masks and
+// serializers still apply.
+type ConsoleWriter struct {
+	Out        io.Writer
+	Color      bool   // ANSI colours; NewConsoleWriter enables them for terminals only
+	TimeFormat string // time layout, DefaultConsoleTimeFormat if empty
+
+	opts []Option
+	now  func() time.Time
+	mu   sync.Mutex
+}
+
+// NewConsoleWriter returns a console writer for w. Colour is enabled when w is
+// a terminal and the NO_COLOR environment variable is not set.
+func NewConsoleWriter(w io.Writer, opts ...Option) *ConsoleWriter {
+	return &ConsoleWriter{
+		Out:   w,
+		Color: isTerminal(w) && os.Getenv("NO_COLOR") == "",
+		opts:  opts,
+	}
+}
+
+// isTerminal reports whether w is a character device such as a TTY.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Log writes one record. Records below Options.Level (INFO by default) are skipped.
+func (c *ConsoleWriter) Log(level LogLevel, msg string, v any) error {
+	return c.write(level, msg, v, c.opts)
+}
+
+// LogContext writes one record with the fields of ctx, see ContextFields.
+func (c *ConsoleWriter) LogContext(ctx context.Context, level LogLevel, msg string, v any) error {
+	opts := c.opts
+	if fields := ContextFields(ctx); len(fields) > 0 {
+		opts = append(append([]Option(nil), opts...), WithFields(fields...))
+	}
+	return c.write(level, msg, v, opts)
+}
+
+func (c *ConsoleWriter) write(level LogLevel, msg string, v any, opts []Option) error {
+	if level < newOptions(opts).Level {
+		return nil
+	}
+	line, _, err := render(v, opts, func(out any, o *Options) ([]byte, error) {
+		// The message goes through the same scrub rules as the record
+		if fn := redactor(o); fn != nil {
+			msg = fn(msg)
+		}
+		return c.line(level, msg, out), nil
+	})
+	if err != nil || line == nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err = c.Out.Write(line)
+	return err
+}
+
+func (c *ConsoleWriter) line(level LogLevel, msg string, out any) []byte {
+	now := time.Now
+	if c.now != nil {
+		now = c.now
+	}
+	layout := c.TimeFormat
+	if layout == "" {
+		layout = DefaultConsoleTimeFormat
+	}
+
+	var buf bytes.Buffer
+	c.paint(&buf, ansiFaint, now().Format(layout))
+	buf.WriteByte(' ')
+	name := level.String()
+	c.paint(&buf, levelColors[level], name)
+	for i := len(name); i < 5; i++ {
+		buf.WriteByte(' ')
+	}
+	if msg != "" {
+		buf.WriteByte(' ')
+		for _, r := range msg {
+			writeEscapedRune(&buf, r)
+		}
+	}
+	writeConsoleFields(&buf, out, c.Color)
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
+
+func (c *ConsoleWriter) paint(buf *bytes.Buffer, color, s string) {
+	if c.Color && color != "" {
+		buf.WriteString(color + s + ansiReset)
+		return
+	}
+	buf.WriteString(s)
+}
+
+// encodeConsole renders the fields of a record without colour, for FormatConsole.
+func encodeConsole(v any) []byte {
+	var buf bytes.Buffer
+	writeConsoleFields(&buf, v, false)
+	return bytes.TrimPrefix(buf.Bytes(), []byte{' '})
+}
+
+// writeConsoleFields writes scalar fields as key=value on the current line and
+// nested objects and arrays as indented JSON blocks below it.
+func writeConsoleFields(buf *bytes.Buffer, v any, color bool) {
+	v = plainTree(v)
+	m, ok := v.(map[string]any)
+	if !ok {
+		if v == nil {
+			return
+		}
+		m = map[string]any{logfmtRootKey: v}
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var nested []string
+	for _, k := range keys {
+		if isNested(m[k]) {
+			nested = append(nested, k)
+			continue
+		}
+		buf.WriteByte(' ')
+		var pair bytes.Buffer
+		writeLogfmt(&pair, k, m[k])
+		if key, value, found := bytes.Cut(pair.Bytes(), []byte{'='}); found && color {
+			buf.WriteString(ansiKey + string(key) + "=" + ansiReset)
+			buf.Write(value)
+		} else {
+			buf.Write(pair.Bytes())
+		}
+	}
+
+	for _, k := range nested {
+		buf.WriteString("\n" + consoleIndent)
+		if color {
+			buf.WriteString(ansiKey + k + ":" + ansiReset + " ")
+		} else {
+			buf.WriteString(k + ": ")
+		}
+		var block bytes.Buffer
+		jsonEnc := json.NewEncoder(&block)
+		jsonEnc.SetEscapeHTML(false)
+		jsonEnc.SetIndent(consoleIndent, "  ")
+		if err := jsonEnc.Encode(m[k]); err == nil {
+			buf.Write(bytes.TrimSuffix(block.Bytes(), []byte{'\n'}))
+		}
+	}
+}
+
+// isNested reports whether v is a non-empty object or array.
+func isNested(v any) bool {
+	switch val := v.(type) {
+	case map[string]any:
+		return len(val) > 0
+	case []any:
+		return len(val) > 0
+	}
+	return false
+}
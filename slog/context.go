@@ -0,0 +1,166 @@
+package slog
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+)
+
+// ----- Context Fields -----
+
+// Field is a key/value pair added to a record as a top-level field.
+type Field struct {
+	Key   string
+	Value any
+}
+
+// Standard field names used by the context helpers.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+	TenantKey  = "tenant"
+	UserIDKey  = "user_id"
+)
+
+// ContextExtractor returns the fields a context contributes to a record.
+type ContextExtractor func(ctx context.Context) []Field
+
+// ctxKey is the type of all context keys owned by this package, so that they
+// cannot collide with keys of other packages.
+type ctxKey int
+
+const (
+	fieldsCtxKey ctxKey = iota
+	spanCtxKey
+)
+
+type registeredExtractor struct {
+	id int
+	fn ContextExtractor
+}
+
+var (
+	extractorMu       sync.RWMutex
+	extractorSeq      int
+	contextExtractors []registeredExtractor // replaced, never modified in place
+)
+
+// RegisterContextExtractor adds an extractor run by MarshalWithContext and ContextFields,
+// after the built-in context fields. Later fields replace earlier fields with the same key.
+// The returned function removes the extractor again.
+func RegisterContextExtractor(fn ContextExtractor) (unregister func()) {
+	if fn == nil {
+		return func() {}
+	}
+	extractorMu.Lock()
+	defer extractorMu.Unlock()
+	extractorSeq++
+	id := extractorSeq
+	contextExtractors = append(contextExtractors[:len(contextExtractors):len(contextExtractors)], registeredExtractor{id: id, fn: fn})
+	return func() {
+		extractorMu.Lock()
+		defer extractorMu.Unlock()
+		kept := make([]registeredExtractor, 0, len(contextExtractors))
+		for _, r := range contextExtractors {
+			if r.id != id {
+				kept = append(kept, r)
+			}
+		}
+		contextExtractors = kept
+	}
+}
+
+// ContextWithFields returns a copy of ctx carrying fields in addition to those already attached.
+func ContextWithFields(ctx context.Context, fields ...Field) context.Context {
+	prev, _ := ctx.Value(fieldsCtxKey).([]Field)
+	all := make([]Field, 0, len(prev)+len(fields))
+	all = append(all, prev...)
+	all = append(all, fields...)
+	return context.WithValue(ctx, fieldsCtxKey, all)
+}
+
+// ContextWithTraceID attaches a trace_id field to ctx.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return ContextWithFields(ctx, Field{Key: TraceIDKey, Value: traceID})
+}
+
+// ContextWithSpanID attaches a span_id field to ctx.
+func ContextWithSpanID(ctx context.Context, spanID string) context.Context {
+	return ContextWithFields(ctx, Field{Key: SpanIDKey, Value: spanID})
+}
+
+// ContextWithTenant attaches a tenant field to ctx.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return ContextWithFields(ctx, Field{Key: TenantKey, Value: tenant})
+}
+
+// ContextWithUserID attaches a user_id field to ctx.
+func ContextWithUserID(ctx context.Context, userID string) context.Context {
+	return ContextWithFields(ctx, Field{Key: UserIDKey, Value: userID})
+}
+
+// ContextFields returns the fields attached to ctx, the trace fields of its span
+// context and the fields of registered extractors, in that order.
+func ContextFields(ctx context.Context) []Field {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsCtxKey).([]Field)
+	fields = append([]Field(nil), fields...)
+	if sc, ok := SpanContextFromContext(ctx); ok {
+		fields = append(fields, sc.Fields()...)
+	}
+
+	extractorMu.RLock()
+	extractors := contextExtractors
+	extractorMu.RUnlock()
+	for _, r := range extractors {
+		fields = append(fields, safeExtract(r.fn, ctx)...)
+	}
+	return fields
+}
+
+// addFields merges Options.Fields into an object record. Fields of the record
+// itself take precedence, later fields replace earlier ones (a nil value is
+// written as null); null and non-object records are left unchanged.
+func (e *encoder) addFields() error {
+	if len(e.opts.Fields) == 0 {
+		return nil
+	}
+	var m map[string]any
+	switch out := e.out.(type) {
+	case map[string]any:
+		m = out
+	case json.RawMessage:
+		var raw map[string]json.RawMessage
+		if json.Unmarshal(out, &raw) != nil {
+			return nil
+		}
+		m = make(map[string]any, len(raw)+len(e.opts.Fields))
+		for k, v := range raw {
+			m[k] = v
+		}
+	default:
+		return nil
+	}
+
+	extra := make(map[string]any, len(e.opts.Fields))
+	for _, f := range e.opts.Fields {
+		if f.Key == "" {
+			continue
+		}
+		sub := e.child(f.Key)
+		if err := sub.encodeReflect(reflect.ValueOf(f.Value)); err != nil {
+			return err
+		}
+		extra[f.Key] = sub.out
+	}
+	for k, v := range extra {
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+	e.out = m
+	return nil
+}
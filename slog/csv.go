@@ -0,0 +1,195 @@
+package slog
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// ----- CSV/TSV Export -----
+
+var marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+
+// csvColumn is one exported column: its dotted header and the output path it reads.
+type csvColumn struct {
+	header string
+	path   []string
+}
+
+// MarshalCSV writes a slice (or array) of tagged structs as CSV. Headers are the
+// slog tag names in declaration order; nested structs become dotted headers
+// ("address.city") and inline structs add their fields directly. Each row goes
+// through the same masks, serializers and options as Marshal and is written as
+// soon as it is encoded. Collections and values from MarshalLog or serializers
+// that are not scalars are written as compact JSON. Text cells starting with =,
+// +, -, @, tab or carriage return get a leading ' so that spreadsheets do not
+// run them as formulas, see WithCSVFormulaGuard.
+func MarshalCSV(w io.Writer, slice any, opts ...Option) error {
+	return marshalDelimited(w, slice, ',', opts)
+}
+
+// MarshalTSV is MarshalCSV with tab-separated columns.
+func MarshalTSV(w io.Writer, slice any, opts ...Option) error {
+	return marshalDelimited(w, slice, '\t', opts)
+}
+
+func marshalDelimited(w io.Writer, slice any, comma rune, opts []Option) error {
+	rv := reflect.ValueOf(slice)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return fmt.Errorf("log: MarshalCSV expects a slice of structs, got %T", slice)
+	}
+	elem := rv.Type().Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return fmt.Errorf("log: MarshalCSV expects a slice of structs, got %T", slice)
+	}
+
+	columns := csvColumns(elem, newOptions(opts))
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.header
+	}
+	if err := cw.Write(headers); err != nil {
+		return err
+	}
+
+	row := make([]string, len(columns))
+	for i := 0; i < rv.Len(); i++ {
+		_, _, err := render(rv.Index(i).Interface(), opts, func(out any, o *Options) ([]byte, error) {
+			out = plainTree(out)
+			for j, c := range columns {
+				row[j] = csvCell(lookupPath(out, c.path), !o.DisableCSVFormulaGuard)
+			}
+			return nil, nil
+		})
+		if err != nil {
+			return fmt.Errorf("log: MarshalCSV row %d: %w", i, err)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvColumns derives the columns of struct type rt.
+func csvColumns(rt reflect.Type, opts *Options) []csvColumn {
+	enc := newEncoder()
+	defer releaseEncoder(enc)
+	enc.opts = opts
+
+	var columns []csvColumn
+	enc.appendColumns(&columns, rt, nil, map[reflect.Type]bool{})
+
+	// Include/Exclude paths apply to columns as they do to fields
+	if sel := newPathSelector(opts.Include, opts.Exclude); sel != nil {
+		kept := columns[:0]
+		for _, c := range columns {
+			if sel.allows(c.path) {
+				kept = append(kept, c)
+			}
+		}
+		columns = kept
+	}
+	return columns
+}
+
+func (e *encoder) appendColumns(columns *[]csvColumn, rt reflect.Type, prefix []string, visiting map[reflect.Type]bool) {
+	visiting[rt] = true
+	defer delete(visiting, rt)
+
+	info := e.getStructInfo(rt)
+	for _, fi := range info.fields {
+		name := fi.jsonName
+		if info.hasLogTag {
+			name = fi.opts.Name
+			if fi.opts.HasLevel && e.opts.Level > fi.opts.Level || e.classAction(fi.opts.Class) == ClassDrop {
+				continue
+			}
+		}
+
+		ft := rt.Field(fi.index).Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if info.hasLogTag && fi.opts.Inline && ft.Kind() == reflect.Struct && !visiting[ft] {
+			e.appendColumns(columns, ft, prefix, visiting)
+			continue
+		}
+		if name == "" || name == "-" {
+			continue
+		}
+
+		path := append(append([]string(nil), prefix...), name)
+		if e.flattens(ft, fi, info.hasLogTag) && !visiting[ft] {
+			e.appendColumns(columns, ft, path, visiting)
+			continue
+		}
+		*columns = append(*columns, csvColumn{header: pathString(path), path: path})
+	}
+}
+
+// flattens reports whether a field of type ft is encoded as a plain object of its own fields.
+func (e *encoder) flattens(ft reflect.Type, fi fieldInfo, tagged bool) bool {
+	if ft.Kind() != reflect.Struct {
+		return false
+	}
+	if tagged && (fi.opts.Serializer != "" || fi.opts.Mask != "" || fi.opts.String || fi.opts.Class != "") {
+		return false
+	}
+	pt := reflect.PointerTo(ft)
+	if !e.opts.DisableLoggerInterface && (ft.Implements(loggerType) || pt.Implements(loggerType)) {
+		return false
+	}
+	if ft.Implements(errorType) || pt.Implements(errorType) {
+		return false
+	}
+	if !e.opts.DisableJSONFallback && (ft.Implements(marshalerType) || pt.Implements(marshalerType)) {
+		return false
+	}
+	return len(e.getStructInfo(ft).fields) > 0
+}
+
+// lookupPath returns the value at path in a plain output tree.
+func lookupPath(v any, path []string) any {
+	for _, seg := range path {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil
+		}
+		v = m[seg]
+	}
+	return v
+}
+
+func csvCell(v any, guard bool) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		// Text a spreadsheet would evaluate as a formula is prefixed with a quote
+		if guard && val != "" && strings.ContainsRune("=+-@\t\r", rune(val[0])) {
+			return "'" + val
+		}
+		return val
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+	return scalarString(v)
+}
@@ -7,7 +7,7 @@ import (
 	"fmt"
 	"math"
 	"reflect"
-	"regexp"
+	"strconv"
 	"strings"
 )
 
@@ -15,8 +15,35 @@ import (
 
 type encoder struct {
 	out     any
-	visited map[uintptr]bool
+	visited map[visitKey]bool
 	opts    *Options
+	state   *encodeState // Per-call state shared with sub-encoders
+
+	// Path tracking: each sub-encoder links to its parent and its own segment,
+	// so the full path is only materialized when needed
+	parent *encoder
+	depth  int // number of path segments from the root
+	seg    string
+	idx    int // collection index when seg is empty and hasIdx is set
+	hasIdx bool
+}
+
+// encodeState holds per-call settings derived from Options, shared by all sub-encoders.
+type encodeState struct {
+	sel    *pathSelector
+	policy *RedactionPolicy // Options.Policy, read once per call
+	trunc  truncation
+	refs   map[visitKey]*refTarget // target of each reference, for RefMode
+	errs   []FieldError            // fields replaced by an error fallback, for MarshalReport
+
+	targets []*refTarget // every occurrence that may be referenced
+	hasRefs bool         // markers were emitted and need resolveRefs
+}
+
+func newEncodeState(opts *Options) *encodeState {
+	return &encodeState{
+		sel: newPathSelector(opts.Include, opts.Exclude),
+	}
 }
 
 func newEncoder() *encoder {
@@ -24,6 +51,10 @@ func newEncoder() *encoder {
 	// Reset state
 	enc.out = nil
 	enc.opts = nil
+	enc.state = nil
+	enc.parent = nil
+	enc.seg = ""
+	enc.hasIdx = false
 	for k := range enc.visited {
 		delete(enc.visited, k)
 	}
@@ -34,6 +65,8 @@ func releaseEncoder(enc *encoder) {
 	// Clear encoder state to prevent memory leaks
 	enc.out = nil
 	enc.opts = nil
+	enc.state = nil
+	enc.parent = nil
 	// Clear visited map more efficiently
 	if len(enc.visited) > 0 {
 		// Only clear if there are entries
@@ -44,30 +77,87 @@ func releaseEncoder(enc *encoder) {
 	encoderPool.Put(enc)
 }
 
+// child returns a sub-encoder sharing e's state, positioned at field or key seg.
+// An empty seg (inline fields) does not add a path segment.
+func (e *encoder) child(seg string) encoder {
+	depth := e.depth
+	if seg != "" {
+		depth++
+	}
+	return encoder{visited: e.visited, opts: e.opts, state: e.state, parent: e, depth: depth, seg: seg}
+}
+
+// childAt returns a sub-encoder sharing e's state, positioned at collection index i.
+func (e *encoder) childAt(i int) encoder {
+	return encoder{visited: e.visited, opts: e.opts, state: e.state, parent: e, depth: e.depth + 1, idx: i, hasIdx: true}
+}
+
+// path returns the segments from the root to e. Collection indices are rendered as "[N]".
+func (e *encoder) path() []string {
+	var segs []string
+	for cur := e; cur != nil; cur = cur.parent {
+		switch {
+		case cur.hasIdx:
+			segs = append(segs, "["+strconv.Itoa(cur.idx)+"]")
+		case cur.seg != "":
+			segs = append(segs, cur.seg)
+		}
+	}
+	for i, j := 0, len(segs)-1; i < j; i, j = i+1, j-1 {
+		segs[i], segs[j] = segs[j], segs[i]
+	}
+	return segs
+}
+
+// pathString renders a path in dotted notation, e.g. "items[3].product.price".
+func pathString(segs []string) string {
+	var b strings.Builder
+	for i, seg := range segs {
+		if i > 0 && !strings.HasPrefix(seg, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(seg)
+	}
+	return b.String()
+}
+
 func (e *encoder) encode(v any) error {
+	if e.state == nil {
+		e.state = newEncodeState(e.opts)
+	}
+	if err := e.loadPolicy(); err != nil {
+		return &MarshalError{Type: reflect.TypeOf(v), Err: err}
+	}
+
 	// Check conditional logging
-	if cl, ok := v.(SConditionalLogger); ok && !cl.ShouldLog() {
-		e.out = nil
-		return nil
+	if cl, ok := v.(SConditionalLogger); ok {
+		show, err := safeShouldLog(cl)
+		if err != nil {
+			return &MarshalError{Type: reflect.TypeOf(v), Err: err}
+		}
+		if !show {
+			e.out = nil
+			return nil
+		}
 	}
 
 	if !e.opts.DisableLoggerInterface {
 		if lg, ok := v.(SLogger); ok {
-			b, err := lg.MarshalLog()
+			b, err := safeMarshalLog(lg)
 			if err != nil {
-				return err
+				return rootError(v, err)
 			}
-			e.out = json.RawMessage(b)
+			e.out = e.maskRaw(e.nearestName(), b)
 			return nil
 		}
 		rv := reflect.ValueOf(v)
 		if rv.Kind() != reflect.Ptr && rv.CanAddr() {
 			if lg, ok := rv.Addr().Interface().(SLogger); ok {
-				b, err := lg.MarshalLog()
+				b, err := safeMarshalLog(lg)
 				if err != nil {
-					return err
+					return rootError(v, err)
 				}
-				e.out = json.RawMessage(b)
+				e.out = e.maskRaw(e.nearestName(), b)
 				return nil
 			}
 		}
@@ -76,6 +166,11 @@ func (e *encoder) encode(v any) error {
 }
 
 func (e *encoder) encodeReflect(rv reflect.Value) error {
+	// Errors are encoded as structured objects (message, type, chain, stack)
+	if e.isErrorValue(rv) {
+		return e.encodeError(rv.Interface().(error))
+	}
+
 	// Handle pointers and circular references
 	for rv.Kind() == reflect.Ptr {
 		if rv.IsNil() {
@@ -83,19 +178,43 @@ func (e *encoder) encodeReflect(rv reflect.Value) error {
 			return nil
 		}
 
-		ptr := rv.Pointer()
-		if e.visited[ptr] {
-			return &MarshalError{
-				Type: rv.Type(),
-				Err:  fmt.Errorf("cyclic reference detected"),
-			}
+		key := visitKey{ptr: rv.Pointer(), typ: rv.Type()}
+		handled, target, err := e.enterRef(key, true)
+		if handled || err != nil {
+			return err
 		}
-		e.visited[ptr] = true
-		defer delete(e.visited, ptr)
+		if target != nil {
+			defer e.recordRef(target)
+		}
+		e.visited[key] = true
+		defer delete(e.visited, key)
 
 		rv = rv.Elem()
 	}
 
+	// Maps and slices can also close a cycle (e.g. through interface values)
+	if (rv.Kind() == reflect.Map || rv.Kind() == reflect.Slice) && !rv.IsNil() && rv.Len() > 0 &&
+		(e.opts.RefMode != RefNone || e.depth >= cycleCheckDepth) {
+		key := visitKey{ptr: rv.Pointer(), typ: rv.Type()}
+		handled, target, err := e.enterRef(key, false)
+		if handled || err != nil {
+			return err
+		}
+		if target != nil {
+			defer e.recordRef(target)
+		}
+		e.visited[key] = true
+		defer delete(e.visited, key)
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Slice, reflect.Array, reflect.Map:
+		if e.atMaxDepth() {
+			e.out = maxDepthMarker
+			return nil
+		}
+	}
+
 	switch rv.Kind() {
 	case reflect.Struct:
 		return e.encodeStruct(rv)
@@ -104,18 +223,23 @@ func (e *encoder) encodeReflect(rv reflect.Value) error {
 			e.out = nil
 			return nil
 		}
-		arr := make([]any, 0, rv.Len())
-		for i := 0; i < rv.Len(); i++ {
-			var sub encoder
-			sub.visited = e.visited
-			sub.opts = e.opts
+		n := e.itemLimit(rv.Len())
+		arr := make([]any, 0, n+1)
+		for i := 0; i < n; i++ {
+			if !e.selectsIndex(i) {
+				continue
+			}
+			sub := e.childAt(i)
 			if err := sub.encodeReflect(rv.Index(i)); err != nil {
 				return err
 			}
 			if sub.out != nil {
-				arr = append(arr, sub.out)
+				arr = append(arr, e.maskDynamic(e.nearestName(), sub.out))
 			}
 		}
+		if n < rv.Len() {
+			arr = append(arr, moreItems(rv.Len()-n))
+		}
 		e.out = arr
 		return nil
 	case reflect.Map:
@@ -124,23 +248,45 @@ func (e *encoder) encodeReflect(rv reflect.Value) error {
 			return nil
 		}
 		m := make(map[string]any)
-		for _, k := range rv.MapKeys() {
-			if k.Kind() != reflect.String {
+		policy := e.policy()
+		keys := rv.MapKeys()
+		if n := e.itemLimit(len(keys)); n < len(keys) {
+			keys = sortedMapKeys(rv)[:n]
+			m[moreItemsKey] = moreItems(rv.Len() - n)
+		}
+		for _, k := range keys {
+			if k.Kind() != reflect.String || !e.selects(k.String()) {
 				continue
 			}
-			var sub encoder
-			sub.visited = e.visited
-			sub.opts = e.opts
+			// Policy rules route matching entries through the tagged-field pipeline
+			if policy != nil {
+				plain := fieldOptions{Name: k.String()}
+				fi, keep := e.applyPolicy(policy, nil, "", fieldInfo{opts: plain})
+				if !keep {
+					continue
+				}
+				if fi.opts != plain {
+					if err := e.encodeField(reflect.StructField{Name: k.String()}, rv.MapIndex(k), fi, m); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			sub := e.child(k.String())
 			if err := sub.encodeReflect(rv.MapIndex(k)); err != nil {
 				return err
 			}
 			if sub.out != nil {
-				m[k.String()] = sub.out
+				m[k.String()] = e.maskDynamic(k.String(), sub.out)
 			}
 		}
 		e.out = m
 		return nil
-	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+	case reflect.String:
+		// Named string types are written as string, so masks and scrub rules apply to them
+		e.out = rv.String()
+		return nil
+	case reflect.Bool, reflect.Float32, reflect.Float64,
 		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		e.out = rv.Interface()
@@ -163,20 +309,20 @@ func (e *encoder) encodeStruct(rv reflect.Value) error {
 	// 1. Struct SLogger (if not disabled)
 	if !e.opts.DisableLoggerInterface {
 		if lg, ok := rv.Interface().(SLogger); ok {
-			b, err := lg.MarshalLog()
+			b, err := safeMarshalLog(lg)
 			if err != nil {
-				return &MarshalError{Type: rt, Err: err}
+				return newMarshalError(rt, "", e.path(), err)
 			}
-			e.out = json.RawMessage(b)
+			e.out = e.maskRaw(e.nearestName(), b)
 			return nil
 		}
 		if rv.CanAddr() {
 			if lg, ok := rv.Addr().Interface().(SLogger); ok {
-				b, err := lg.MarshalLog()
+				b, err := safeMarshalLog(lg)
 				if err != nil {
-					return &MarshalError{Type: rt, Err: err}
+					return newMarshalError(rt, "", e.path(), err)
 				}
-				e.out = json.RawMessage(b)
+				e.out = e.maskRaw(e.nearestName(), b)
 				return nil
 			}
 		}
@@ -188,12 +334,23 @@ func (e *encoder) encodeStruct(rv reflect.Value) error {
 	// 2. Has log tags → process only these fields
 	if info.hasLogTag {
 		m := make(map[string]any)
+		policy := e.policy()
 		for _, fi := range info.fields {
 			fv := rv.Field(fi.index)
 			sf := rt.Field(fi.index)
 
+			if !fi.opts.Inline && !e.selects(fi.opts.Name) {
+				continue
+			}
+			if policy != nil && fi.opts.Name != "-" {
+				var keep bool
+				if fi, keep = e.applyPolicy(policy, rt, sf.Name, fi); !keep {
+					continue
+				}
+			}
+
 			if err := e.encodeField(sf, fv, fi, m); err != nil {
-				return &MarshalError{Type: rt, Field: sf.Name, Err: err}
+				return e.fieldError(rt, sf.Name, fi.opts.Name, err)
 			}
 		}
 		e.out = m
@@ -203,20 +360,20 @@ func (e *encoder) encodeStruct(rv reflect.Value) error {
 	// 3. json.Marshaler (if fallback not disabled)
 	if !e.opts.DisableJSONFallback {
 		if mj, ok := rv.Interface().(json.Marshaler); ok {
-			b, err := mj.MarshalJSON()
+			b, err := safeMarshalJSON(mj)
 			if err != nil {
-				return &MarshalError{Type: rt, Err: err}
+				return newMarshalError(rt, "", e.path(), err)
 			}
-			e.out = json.RawMessage(b)
+			e.out = e.maskRaw(e.nearestName(), b)
 			return nil
 		}
 		if rv.CanAddr() {
 			if mj, ok := rv.Addr().Interface().(json.Marshaler); ok {
-				b, err := mj.MarshalJSON()
+				b, err := safeMarshalJSON(mj)
 				if err != nil {
-					return &MarshalError{Type: rt, Err: err}
+					return newMarshalError(rt, "", e.path(), err)
 				}
-				e.out = json.RawMessage(b)
+				e.out = e.maskRaw(e.nearestName(), b)
 				return nil
 			}
 		}
@@ -226,24 +383,43 @@ func (e *encoder) encodeStruct(rv reflect.Value) error {
 	if !e.opts.DisableJSONFallback {
 		m := make(map[string]any)
 		for _, fi := range info.fields {
-			if fi.jsonName == "" || fi.jsonName == "-" {
+			if fi.jsonName == "" || fi.jsonName == "-" || !e.selects(fi.jsonName) {
 				continue
 			}
 			fv := rv.Field(fi.index)
 			sf := rt.Field(fi.index)
 
-			if fi.jsonOpts.Contains("omitempty") && isEmpty(fv) {
-				continue
+			if fi.jsonOpts.Contains("omitempty") {
+				empty, err := safeIsEmpty(fv)
+				if err != nil {
+					return e.fieldError(rt, sf.Name, fi.jsonName, err)
+				}
+				if empty {
+					continue
+				}
+			}
+
+			// Policy rules route matching fields through the tagged-field pipeline
+			if policy := e.policy(); policy != nil {
+				plain := fieldOptions{Name: fi.jsonName}
+				pfi, keep := e.applyPolicy(policy, rt, sf.Name, fieldInfo{index: fi.index, opts: plain})
+				if !keep {
+					continue
+				}
+				if pfi.opts != plain {
+					if err := e.encodeField(sf, fv, pfi, m); err != nil {
+						return e.fieldError(rt, sf.Name, fi.jsonName, err)
+					}
+					continue
+				}
 			}
 
-			var sub encoder
-			sub.visited = e.visited
-			sub.opts = e.opts
+			sub := e.child(fi.jsonName)
 			if err := sub.encodeReflect(fv); err != nil {
-				return &MarshalError{Type: rt, Field: sf.Name, Err: err}
+				return e.fieldError(rt, sf.Name, fi.jsonName, err)
 			}
 			if sub.out != nil {
-				m[fi.jsonName] = sub.out
+				m[fi.jsonName] = e.maskDynamic(fi.jsonName, sub.out)
 			}
 		}
 		e.out = m
@@ -261,21 +437,46 @@ func (e *encoder) encodeField(sf reflect.StructField, fv reflect.Value, fi field
 		return nil
 	}
 
+	// Level visibility: level=debug fields are only emitted at DEBUG
+	if fi.opts.HasLevel && e.opts.Level > fi.opts.Level {
+		return nil
+	}
+
+	// Data classification: dropped classes are never serialized, masked classes
+	// bypass custom serializers so that the policy mask always applies
+	if e.classAction(fi.opts.Class) == ClassDrop {
+		return nil
+	}
+	if e.classMasked(fi) {
+		if fi.opts.OmitEmpty || e.opts.OmitEmptyByDefault {
+			if skip, err := e.omitEmpty(sf, fv, fi, m); skip || err != nil {
+				return err
+			}
+		}
+		return e.encodeBasic(fv, sf, fi, m)
+	}
+
 	// Check conditional logging
 	if cl, ok := fv.Interface().(SConditionalLogger); ok {
-		if !cl.ShouldLog() {
+		show, err := safeShouldLog(cl)
+		if err != nil {
+			if e.opts.EnableErrorFallback {
+				m[fi.opts.Name] = e.errorFallback(sf, fv, fi, err)
+				return nil
+			}
+			return err
+		}
+		if !show {
 			return nil
 		}
 		// If ShouldLog() returns true, serialize the entire struct using JSON marshaling
 		// This preserves all struct fields, not just those with log tags
-		var sub encoder
-		sub.visited = e.visited
-		sub.opts = e.opts
+		sub := e.child(fi.opts.Name)
 		if err := sub.encodeReflect(fv); err != nil {
 			// If error fallback is enabled, output error info
 			if e.opts.EnableErrorFallback {
-				errorStr := e.createErrorString(sf.Name, fv, err)
-				m[fi.opts.Name] = errorStr
+				fallback := e.errorFallback(sf, fv, fi, err)
+				m[fi.opts.Name] = fallback
 				return nil
 			}
 			return err
@@ -289,8 +490,10 @@ func (e *encoder) encodeField(sf reflect.StructField, fv reflect.Value, fi field
 	// Priority: Field log:ser=xxx → Field Struct SLogger → Basic Type → Mask
 
 	// Early check for omitempty (both field-level and global)
-	if (fi.opts.OmitEmpty || e.opts.OmitEmptyByDefault) && isEmpty(fv) {
-		return nil
+	if fi.opts.OmitEmpty || e.opts.OmitEmptyByDefault {
+		if skip, err := e.omitEmpty(sf, fv, fi, m); skip || err != nil {
+			return err
+		}
 	}
 
 	// 1. Field log:"ser=xxx" (field-level custom serializer - highest priority)
@@ -301,18 +504,18 @@ func (e *encoder) encodeField(sf reflect.StructField, fv reflect.Value, fi field
 	// 2. Field Struct SLogger (field with SLogger interface)
 	if !e.opts.DisableLoggerInterface {
 		if lg, ok := fv.Interface().(SLogger); ok && fv.CanInterface() {
-			b, err := lg.MarshalLog()
+			b, err := safeMarshalLog(lg)
 			if err != nil {
 				// If error fallback is enabled, output error info
 				if e.opts.EnableErrorFallback {
-					errorStr := e.createErrorString(sf.Name, fv, err)
-					m[fi.opts.Name] = errorStr
+					fallback := e.errorFallback(sf, fv, fi, err)
+					m[fi.opts.Name] = fallback
 					return nil
 				}
 				return err
 			}
 			// Note: SLogger interface results are not subject to omitempty
-			m[fi.opts.Name] = json.RawMessage(b)
+			m[fi.opts.Name] = e.maskRaw(fi.opts.Name, b)
 			return nil
 		}
 	}
@@ -321,21 +524,71 @@ func (e *encoder) encodeField(sf reflect.StructField, fv reflect.Value, fi field
 	return e.encodeBasic(fv, sf, fi, m)
 }
 
-// createErrorString creates a string containing field name, raw value, and error info.
-func (e *encoder) createErrorString(fieldName string, fv reflect.Value, err error) string {
+// fieldError wraps err for the field goName (output name) of rt. Errors that
+// already carry the path of a deeper value are returned unchanged.
+func (e *encoder) fieldError(rt reflect.Type, goName, name string, err error) error {
+	if me, ok := err.(*MarshalError); ok && me.Path != "" {
+		return me
+	}
+	return newMarshalError(rt, goName, append(e.path(), name), err)
+}
+
+// errorFallback records a failed field and returns the object written in its place:
+// {"_error":{"field":..., "path":..., "value":..., "error":...}}.
+func (e *encoder) errorFallback(sf reflect.StructField, fv reflect.Value, fi fieldInfo, err error) map[string]any {
+	value, hasValue := e.fallbackValue(sf, fv, fi)
+
+	path := pathString(append(e.path(), fi.opts.Name))
+	fe := FieldError{Path: path, Field: sf.Name, Err: err}
+	if fv.IsValid() {
+		fe.Type = fv.Type()
+	}
+	if e.state != nil {
+		e.state.errs = append(e.state.errs, fe)
+	}
+
+	info := map[string]any{
+		"field": sf.Name,
+		"path":  path,
+		"error": safeErrorString(err),
+	}
+	if hasValue {
+		info["value"] = value
+	}
+	return map[string]any{errorKey: info}
+}
+
+// fallbackValue returns the value reported by errorFallback. Fields with a class,
+// policy or field mask report the masked form of scalars and omit anything else.
+func (e *encoder) fallbackValue(sf reflect.StructField, fv reflect.Value, fi fieldInfo) (string, bool) {
 	// Safely get string representation of field value
 	valueStr := e.safeValueToString(fv)
 
+	action := e.classAction(fi.opts.Class)
+	masked := action != "" && action != ClassKeep
+	if action == "" && (e.opts.MaskSensitive || fi.opts.Mask != "") {
+		action, masked = fi.opts.Mask, true
+		if action == "" {
+			action = defaultMK
+		}
+	}
+	if masked {
+		if !fv.IsValid() {
+			return "", false
+		}
+		switch derefType(fv.Type()).Kind() {
+		case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+			return e.getMask(action)(valueStr), true
+		}
+		return "", false
+	}
+
 	// Check if field name or value contains sensitive information
-	if e.containsSensitiveData(fieldName, valueStr) {
+	if e.containsSensitiveData(sf.Name, valueStr) {
 		valueStr = "<sensitive>"
 	}
-
-	// Build error info string
-	errorInfo := fmt.Sprintf("FIELD_SERIALIZE_ERROR{field:%s, value:%s, error:%s}",
-		fieldName, valueStr, err.Error())
-
-	return errorInfo
+	return valueStr, true
 }
 
 // encodeWithLogger handles encoding using the SLogger interface (highest priority)
@@ -345,18 +598,18 @@ func (e *encoder) encodeWithLogger(fv reflect.Value, sf reflect.StructField, fi
 	}
 
 	if lg, ok := fv.Interface().(SLogger); ok && fv.CanInterface() {
-		b, err := lg.MarshalLog()
+		b, err := safeMarshalLog(lg)
 		if err != nil {
 			// If error fallback is enabled, output error info
 			if e.opts.EnableErrorFallback {
-				errorStr := e.createErrorString(sf.Name, fv, err)
-				m[fi.opts.Name] = errorStr
+				fallback := e.errorFallback(sf, fv, fi, err)
+				m[fi.opts.Name] = fallback
 				return true, nil
 			}
 			return true, err
 		}
 		// Note: SLogger interface results are not subject to omitempty
-		m[fi.opts.Name] = json.RawMessage(b)
+		m[fi.opts.Name] = e.maskRaw(fi.opts.Name, b)
 		return true, nil
 	}
 	return false, nil
@@ -381,23 +634,23 @@ func (e *encoder) encodeWithSerializer(fv reflect.Value, sf reflect.StructField,
 	if !ok {
 		// Serializer not found - handle according to error fallback setting
 		if e.opts.EnableErrorFallback {
-			errorStr := e.createErrorString(sf.Name, fv, fmt.Errorf("serializer '%s' not found", fi.opts.Serializer))
-			m[fi.opts.Name] = errorStr
+			fallback := e.errorFallback(sf, fv, fi, fmt.Errorf("serializer '%s' not found", fi.opts.Serializer))
+			m[fi.opts.Name] = fallback
 			return true, nil
 		}
-		return true, &MarshalError{Type: fv.Type(), Field: sf.Name, Err: fmt.Errorf("serializer '%s' not found", fi.opts.Serializer)}
+		return true, newMarshalError(fv.Type(), sf.Name, append(e.path(), fi.opts.Name), fmt.Errorf("serializer '%s' not found", fi.opts.Serializer))
 	}
 
 	// Serializer found, execute it
-	b, err := fn(fv.Interface())
+	b, err := safeSerialize(fi.opts.Serializer, fn, fv.Interface())
 	if err != nil {
 		// Serializer error - handle according to error fallback setting
 		if e.opts.EnableErrorFallback {
-			errorStr := e.createErrorString(sf.Name, fv, err)
-			m[fi.opts.Name] = errorStr
+			fallback := e.errorFallback(sf, fv, fi, err)
+			m[fi.opts.Name] = fallback
 			return true, nil
 		}
-		return true, &MarshalError{Type: fv.Type(), Field: sf.Name, Err: err}
+		return true, newMarshalError(fv.Type(), sf.Name, append(e.path(), fi.opts.Name), err)
 	}
 
 	// Note: Custom serializer results are not subject to omitempty
@@ -408,18 +661,16 @@ func (e *encoder) encodeWithSerializer(fv reflect.Value, sf reflect.StructField,
 // encodeBasic handles basic type serialization and post-processing
 func (e *encoder) encodeBasic(fv reflect.Value, sf reflect.StructField, fi fieldInfo, m map[string]any) error {
 	// Inline field handling (for struct types)
-	if fi.opts.Inline && fv.Kind() == reflect.Struct {
+	if fi.opts.Inline && fv.Kind() == reflect.Struct && !e.classMasked(fi) {
 		return e.encodeInlineField(fv, sf, fi, m)
 	}
 
-	var sub encoder
-	sub.visited = e.visited
-	sub.opts = e.opts
+	sub := e.child(fi.opts.Name)
 	if err := sub.encodeReflect(fv); err != nil {
 		// If error fallback is enabled, output error info
 		if e.opts.EnableErrorFallback {
-			errorStr := e.createErrorString(sf.Name, fv, err)
-			m[fi.opts.Name] = errorStr
+			fallback := e.errorFallback(sf, fv, fi, err)
+			m[fi.opts.Name] = fallback
 			return nil
 		}
 		return err
@@ -432,14 +683,12 @@ func (e *encoder) encodeBasic(fv reflect.Value, sf reflect.StructField, fi field
 
 // encodeInlineField handles inline field processing for struct types
 func (e *encoder) encodeInlineField(fv reflect.Value, sf reflect.StructField, fi fieldInfo, m map[string]any) error {
-	var sub encoder
-	sub.visited = e.visited
-	sub.opts = e.opts
+	sub := e.child("")
 	if err := sub.encodeReflect(fv); err != nil {
 		// If error fallback is enabled, output error info
 		if e.opts.EnableErrorFallback {
-			errorStr := e.createErrorString(sf.Name, fv, err)
-			m[fi.opts.Name] = errorStr
+			fallback := e.errorFallback(sf, fv, fi, err)
+			m[fi.opts.Name] = fallback
 			return nil
 		}
 		return err
@@ -454,37 +703,8 @@ func (e *encoder) encodeInlineField(fv reflect.Value, sf reflect.StructField, fi
 
 // containsSensitiveData checks if field name or value contains sensitive information
 func (e *encoder) containsSensitiveData(fieldName, value string) bool {
-	// Check field name for sensitive keywords
-	sensitiveFieldNames := []string{
-		"password", "passwd", "pwd", "secret", "token", "key",
-		"credential", "auth", "private", "credit", "ssn",
-		"phone", "email", "address", "card", "bank",
-	}
-
-	lowerFieldName := strings.ToLower(fieldName)
-	for _, sensitive := range sensitiveFieldNames {
-		if strings.Contains(lowerFieldName, sensitive) {
-			return true
-		}
-	}
-
-	// Check value patterns for sensitive data
-	// Email pattern
-	if strings.Contains(value, "@") && strings.Contains(value, ".") {
-		return true
-	}
-
-	// Phone number pattern (simple check for 11 digits)
-	if matched, _ := regexp.MatchString(`\d{11}`, value); matched {
-		return true
-	}
-
-	// Credit card pattern (16 digits with possible spaces/dashes)
-	if matched, _ := regexp.MatchString(`\d{4}[-\s]?\d{4}[-\s]?\d{4}[-\s]?\d{4}`, value); matched {
-		return true
-	}
-
-	return false
+	d := e.sensitive()
+	return d.IsSensitiveKey(fieldName) || d.MatchValue(value)
 }
 
 // applyFieldPostProcessing applies mask, precision, and string formatting to field values
@@ -493,14 +713,21 @@ func (e *encoder) applyFieldPostProcessing(fi fieldInfo, fieldName string, fv re
 		return
 	}
 
-	// Masking
-	if (e.opts.MaskSensitive || fi.opts.Mask != "") && val != nil {
+	// Masking (class policy takes precedence over field and global masks)
+	action := e.classAction(fi.opts.Class)
+	switch {
+	case action == ClassKeep:
+		// Exposed by policy, no masking
+	case action != "":
+		val = e.maskClassValue(action, val)
+	case fi.opts.Mask != "":
+		// Field masks also apply to the string form of numbers, e.g. hashed numeric IDs
+		if s, ok := scalarText(val); ok {
+			val = e.getMask(fi.opts.Mask)(s)
+		}
+	case e.opts.MaskSensitive:
 		if s, ok := val.(string); ok {
-			maskName := fi.opts.Mask
-			if maskName == "" {
-				maskName = defaultMK
-			}
-			val = getMask(maskName)(s)
+			val = e.getMask(defaultMK)(s)
 		}
 	}
 
@@ -525,6 +752,35 @@ func (e *encoder) applyFieldPostProcessing(fi fieldInfo, fieldName string, fv re
 	}
 }
 
+// applyPolicy merges the first policy rule matching field fi of owner into its options.
+// It reports false if the rule omits the field.
+func (e *encoder) applyPolicy(p *RedactionPolicy, owner reflect.Type, goName string, fi fieldInfo) (fieldInfo, bool) {
+	return applyPolicyAt(p, e.path(), owner, goName, fi)
+}
+
+// applyPolicyAt is applyPolicy for a field whose parent is at path.
+func applyPolicyAt(p *RedactionPolicy, path []string, owner reflect.Type, goName string, fi fieldInfo) (fieldInfo, bool) {
+	rule, ok := p.match(append(path, fi.opts.Name), owner, goName)
+	if !ok {
+		return fi, true
+	}
+	fi.opts, ok = rule.apply(fi.opts)
+	return fi, ok
+}
+
+// getMask resolves a mask by name, preferring per-Options providers over the global registry.
+func (e *encoder) getMask(name string) MaskFunc {
+	if e.opts.Hasher != nil {
+		if fn := e.opts.Hasher.mask(name); fn != nil {
+			return safeMask(fn)
+		}
+	}
+	if name == "encrypt" && e.opts.KeyProvider != nil {
+		return safeMask(encryptMask(e.opts.KeyProvider))
+	}
+	return safeMask(getMask(name))
+}
+
 // safeValueToString safely converts field value to string, avoiding panic.
 func (e *encoder) safeValueToString(fv reflect.Value) (str string) {
 	defer func() {
@@ -325,14 +325,14 @@ func TestNonExistentSerializer(t *testing.T) {
 	}
 	t.Logf("testNonExistentSerializer Marshal result: %s", string(result))
 
-	var parsed map[string]string
+	var parsed map[string]map[string]map[string]string
 	if err := json.Unmarshal(result, &parsed); err != nil {
 		t.Fatalf("Unmarshal failed: %v", err)
 	}
 
 	// Should use error fallback since serializer doesn't exist
-	if !contains(parsed["field"], "FIELD_SERIALIZE_ERROR") {
-		t.Errorf("Expected error fallback message, got %v", parsed["field"])
+	if parsed["field"][errorKey]["error"] == "" {
+		t.Errorf("Expected error fallback object, got %v", parsed["field"])
 	}
 }
 
@@ -616,14 +616,14 @@ func testFieldSerializerError(t *testing.T) {
 		t.Fatalf("Marshal failed: %v", err)
 	}
 
-	var parsed map[string]string
+	var parsed map[string]map[string]map[string]string
 	if err := json.Unmarshal(result, &parsed); err != nil {
 		t.Fatalf("Unmarshal failed: %v", err)
 	}
 
 	// Should contain error message
-	if !contains(parsed["field"], "FIELD_SERIALIZE_ERROR") {
-		t.Errorf("Expected error fallback message, got %v", parsed["field"])
+	if parsed["field"][errorKey]["error"] == "" {
+		t.Errorf("Expected error fallback object, got %v", parsed["field"])
 	}
 }
 
@@ -640,14 +640,14 @@ func testFieldLoggerError(t *testing.T) {
 		t.Fatalf("Marshal failed: %v", err)
 	}
 
-	var parsed map[string]string
+	var parsed map[string]map[string]map[string]string
 	if err := json.Unmarshal(result, &parsed); err != nil {
 		t.Fatalf("Unmarshal failed: %v", err)
 	}
 
 	// Should contain error message
-	if !contains(parsed["field"], "FIELD_SERIALIZE_ERROR") {
-		t.Errorf("Expected error fallback message, got %v", parsed["field"])
+	if parsed["field"][errorKey]["error"] == "" {
+		t.Errorf("Expected error fallback object, got %v", parsed["field"])
 	}
 }
 
@@ -830,16 +830,16 @@ func TestMissingSerializerErrorHandling(t *testing.T) {
 		t.Fatalf("Expected no error with error fallback enabled, got: %v", err1)
 	}
 
-	var parsed1 map[string]string
+	var parsed1 map[string]map[string]map[string]string
 	if err := json.Unmarshal(result1, &parsed1); err != nil {
 		t.Fatalf("Unmarshal failed: %v", err)
 	}
 
 	// Should contain error message about missing serializer
-	if !contains(parsed1["field"], "FIELD_SERIALIZE_ERROR") {
-		t.Errorf("Expected error fallback message, got: %v", parsed1["field"])
+	if parsed1["field"][errorKey]["error"] == "" {
+		t.Errorf("Expected error fallback object, got: %v", parsed1["field"])
 	}
-	if !contains(parsed1["field"], "missing_serializer") {
+	if !contains(parsed1["field"][errorKey]["error"], "missing_serializer") {
 		t.Errorf("Expected error message to contain serializer name, got: %v", parsed1["field"])
 	}
 
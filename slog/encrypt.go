@@ -0,0 +1,248 @@
+package slog
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ----- Reversible Field Encryption -----
+
+const envelopePrefix = "enc:v1:"
+
+// sealedEnvelope matches a complete envelope: prefix, key ID and base64url payload.
+var sealedEnvelope = regexp.MustCompile(`^enc:v1:[^:\s]+:[A-Za-z0-9_-]+$`)
+
+// KeyProvider supplies AES keys (16, 24 or 32 bytes) for the encrypt mask.
+// ActiveKey is used to seal new values; Key resolves the key ID found in an envelope.
+type KeyProvider interface {
+	ActiveKey() (id string, key []byte, err error)
+	Key(id string) ([]byte, error)
+}
+
+// MemoryKeyProvider is an in-memory KeyProvider, suitable for tests and for
+// processes that load their keys at startup.
+type MemoryKeyProvider struct {
+	mu       sync.RWMutex
+	keys     map[string][]byte
+	activeID string
+}
+
+// NewMemoryKeyProvider creates a provider whose active key is (id, key).
+func NewMemoryKeyProvider(id string, key []byte) *MemoryKeyProvider {
+	p := &MemoryKeyProvider{keys: make(map[string][]byte)}
+	p.Rotate(id, key)
+	return p
+}
+
+// AddKey registers a key that can be used for decryption only.
+func (p *MemoryKeyProvider) AddKey(id string, key []byte) {
+	if id == "" || len(key) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[id] = append([]byte(nil), key...)
+}
+
+// Rotate registers (id, key) and makes it the active encryption key.
+func (p *MemoryKeyProvider) Rotate(id string, key []byte) {
+	if id == "" || len(key) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.keys[id] = append([]byte(nil), key...)
+	p.activeID = id
+}
+
+func (p *MemoryKeyProvider) ActiveKey() (string, []byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.activeID == "" {
+		return "", nil, errors.New("no active encryption key")
+	}
+	return p.activeID, p.keys[p.activeID], nil
+}
+
+func (p *MemoryKeyProvider) Key(id string) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	key, ok := p.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", id)
+	}
+	return key, nil
+}
+
+// Encrypt seals s with AES-GCM under the provider's active key and returns a
+// compact envelope: "enc:v1:<keyID>:<base64url(nonce|ciphertext)>".
+// The key ID is bound to the ciphertext as additional authenticated data.
+func Encrypt(kp KeyProvider, s string) (string, error) {
+	if kp == nil {
+		return "", errors.New("no key provider configured")
+	}
+	id, key, err := kp.ActiveKey()
+	if err != nil {
+		return "", err
+	}
+	if strings.ContainsRune(id, ':') {
+		return "", fmt.Errorf("invalid key ID %q", id)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(s), []byte(id))
+	return envelopePrefix + id + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens an envelope produced by Encrypt (or the encrypt mask).
+func Decrypt(kp KeyProvider, envelope string) (string, error) {
+	if kp == nil {
+		return "", errors.New("no key provider configured")
+	}
+	if !IsEnvelope(envelope) {
+		return "", errors.New("not an encrypted envelope")
+	}
+	rest := envelope[len(envelopePrefix):]
+	i := strings.IndexByte(rest, ':')
+	if i <= 0 {
+		return "", errors.New("malformed envelope")
+	}
+	id := rest[:i]
+	sealed, err := base64.RawURLEncoding.DecodeString(rest[i+1:])
+	if err != nil {
+		return "", fmt.Errorf("malformed envelope: %w", err)
+	}
+	key, err := kp.Key(id)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("malformed envelope: ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", fmt.Errorf("decrypt with key %q: %w", id, err)
+	}
+	return string(plain), nil
+}
+
+// IsEnvelope reports whether s looks like an encrypted envelope.
+func IsEnvelope(s string) bool {
+	return strings.HasPrefix(s, envelopePrefix)
+}
+
+// isSealed reports whether s is exactly one well-formed envelope, with nothing
+// appended that could hide other content.
+func isSealed(s string) bool {
+	return sealedEnvelope.MatchString(s)
+}
+
+// DecryptFields re-opens a log line produced by Marshal, replacing every
+// encrypted envelope (at any depth) with its plaintext.
+func DecryptFields(data []byte, kp KeyProvider) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	v, err := decryptValue(kp, v)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	jsonEnc := json.NewEncoder(&buf)
+	jsonEnc.SetEscapeHTML(false)
+	if err := jsonEnc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+func decryptValue(kp KeyProvider, v any) (any, error) {
+	switch val := v.(type) {
+	case string:
+		if IsEnvelope(val) {
+			return Decrypt(kp, val)
+		}
+	case map[string]any:
+		for k, sub := range val {
+			out, err := decryptValue(kp, sub)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", k, err)
+			}
+			val[k] = out
+		}
+	case []any:
+		for i, sub := range val {
+			out, err := decryptValue(kp, sub)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %w", i, err)
+			}
+			val[i] = out
+		}
+	}
+	return v, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptMask returns a MaskFunc sealing values with kp.
+// If encryption is not possible the value is masked instead, never emitted in clear.
+func encryptMask(kp KeyProvider) MaskFunc {
+	return func(s string) string {
+		env, err := Encrypt(kp, s)
+		if err != nil {
+			return defaultMask(s)
+		}
+		return env
+	}
+}
+
+// ----- Process-wide Key Provider -----
+
+var (
+	defaultKeyProvider   KeyProvider
+	defaultKeyProviderMu sync.RWMutex
+)
+
+// SetKeyProvider sets the process-wide key provider used by the encrypt mask.
+func SetKeyProvider(kp KeyProvider) {
+	defaultKeyProviderMu.Lock()
+	defer defaultKeyProviderMu.Unlock()
+	defaultKeyProvider = kp
+}
+
+func getKeyProvider() KeyProvider {
+	defaultKeyProviderMu.RLock()
+	defer defaultKeyProviderMu.RUnlock()
+	return defaultKeyProvider
+}
@@ -0,0 +1,171 @@
+package slog
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+// ----- Rich Error Serialization -----
+
+// LogError is implemented by domain errors that carry a machine-readable code.
+type LogError interface {
+	error
+	ErrorCode() string
+}
+
+// stackTraceMethod is looked up on errors through reflection, so that both
+// StackTrace() []uintptr and github.com/pkg/errors' uintptr-based frames work.
+const stackTraceMethod = "StackTrace"
+
+// maxErrorChain bounds Unwrap traversal in case of self-referencing errors.
+const maxErrorChain = 32
+
+var (
+	errorType  = reflect.TypeOf((*error)(nil)).Elem()
+	loggerType = reflect.TypeOf((*SLogger)(nil)).Elem()
+)
+
+// isErrorValue reports whether rv should be encoded as a rich error.
+// SLogger implementations and slog-tagged structs keep priority over the error
+// structure.
+func (e *encoder) isErrorValue(rv reflect.Value) bool {
+	if !rv.IsValid() || !rv.CanInterface() {
+		return false
+	}
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return false
+		}
+	}
+	t := rv.Type()
+	if rv.Kind() == reflect.Interface {
+		t = rv.Elem().Type()
+	}
+	if !t.Implements(errorType) {
+		return false
+	}
+	// Structs with slog tags keep their tagged fields (and masks) instead of Error()
+	if st := derefType(t); st.Kind() == reflect.Struct && e.getStructInfo(st).hasLogTag {
+		return false
+	}
+	return e.opts.DisableLoggerInterface || !t.Implements(loggerType)
+}
+
+// encodeError encodes err as {"message","type","code","chain","errors","stack"}.
+// A panic in the error's own methods is reported as a MarshalError.
+func (e *encoder) encodeError(err error) (encErr error) {
+	defer func() {
+		if r := recover(); r != nil {
+			encErr = newMarshalError(reflect.TypeOf(err), "", e.path(), &PanicError{Method: "Error", Value: r})
+		}
+	}()
+	e.out = errorObject(err, 0)
+	return nil
+}
+
+func errorObject(err error, depth int) map[string]any {
+	m := map[string]any{
+		"message": err.Error(),
+		"type":    fmt.Sprintf("%T", err),
+	}
+	if code := errorCode(err); code != "" {
+		m["code"] = code
+	}
+	if depth >= maxErrorChain {
+		return m
+	}
+
+	// errors.Join and other multi-errors: each child gets its own structure
+	if multi, ok := err.(interface{ Unwrap() []error }); ok {
+		var children []any
+		for _, child := range multi.Unwrap() {
+			if child != nil {
+				children = append(children, errorObject(child, depth+1))
+			}
+		}
+		if len(children) > 0 {
+			m["errors"] = children
+		}
+	}
+
+	// Unwrap chain, outermost cause first
+	var chain []any
+	stack := errorStack(err)
+	cur := err
+	for i := 0; i < maxErrorChain; i++ {
+		cur = errors.Unwrap(cur)
+		if cur == nil {
+			break
+		}
+		link := map[string]any{
+			"message": cur.Error(),
+			"type":    fmt.Sprintf("%T", cur),
+		}
+		if code := errorCode(cur); code != "" {
+			link["code"] = code
+		}
+		chain = append(chain, link)
+		// The innermost stack is closest to the origin of the error
+		if s := errorStack(cur); s != nil {
+			stack = s
+		}
+	}
+	if len(chain) > 0 {
+		m["chain"] = chain
+	}
+	if len(stack) > 0 {
+		m["stack"] = stack
+	}
+	return m
+}
+
+func errorCode(err error) string {
+	if le, ok := err.(LogError); ok {
+		return le.ErrorCode()
+	}
+	return ""
+}
+
+// errorStack returns formatted frames for errors exposing a StackTrace() method
+// that returns program counters or strings.
+func errorStack(err error) []any {
+	method := reflect.ValueOf(err).MethodByName(stackTraceMethod)
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+	out := method.Call(nil)[0]
+	if out.Kind() != reflect.Slice || out.Len() == 0 {
+		return nil
+	}
+
+	switch out.Type().Elem().Kind() {
+	case reflect.Uintptr:
+		pcs := make([]uintptr, out.Len())
+		for i := range pcs {
+			pcs[i] = uintptr(out.Index(i).Uint())
+		}
+		var frames []any
+		iter := runtime.CallersFrames(pcs)
+		for {
+			f, more := iter.Next()
+			if f.Function != "" || f.File != "" {
+				frames = append(frames, f.Function+" "+f.File+":"+strconv.Itoa(f.Line))
+			}
+			if !more {
+				break
+			}
+		}
+		return frames
+	case reflect.String:
+		frames := make([]any, out.Len())
+		for i := range frames {
+			frames[i] = out.Index(i).String()
+		}
+		return frames
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// ----- Output Formats -----
+
+// Format selects how MarshalWithOpts renders the encoded record. All formats
+// share field selection, masks, serializers and redaction.
+type Format int
+
+const (
+	FormatJSON    Format = iota // JSON (default)
+	FormatLogfmt                // key=value pairs, nested values flattened to dotted keys
+	FormatConsole               // human-readable key=value with indented nested values, see ConsoleWriter
+	FormatCBOR                  // CBOR (RFC 8949), see DecodeCBOR
+	FormatMsgpack               // MessagePack, see DecodeMsgpack
+)
+
+// encodeFormat renders v in a non-JSON format.
+func encodeFormat(o *Options, v any) ([]byte, error) {
+	switch o.Format {
+	case FormatLogfmt:
+		return encodeLogfmt(v), nil
+	case FormatConsole:
+		return encodeConsole(v), nil
+	case FormatCBOR:
+		w := &cborWriter{}
+		err := writeBinary(w, v, o.EmbedRawJSON)
+		return w.buf, err
+	case FormatMsgpack:
+		w := &msgpackWriter{}
+		err := writeBinary(w, v, o.EmbedRawJSON)
+		return w.buf, err
+	}
+	return nil, fmt.Errorf("log: unknown format %d", int(o.Format))
+}
+
+// plainTree resolves json.RawMessage values (from MarshalLog, MarshalJSON and
+// serializers) into maps, slices and scalars. Numbers are kept as json.Number.
+func plainTree(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, sub := range val {
+			out[k] = plainTree(sub)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, sub := range val {
+			out[i] = plainTree(sub)
+		}
+		return out
+	case json.RawMessage:
+		dec := json.NewDecoder(bytes.NewReader(val))
+		dec.UseNumber()
+		var decoded any
+		if err := dec.Decode(&decoded); err != nil {
+			return string(val)
+		}
+		return decoded
+	}
+	return v
+}
+
+// scalarString renders a non-container value as in JSON, but without quoting strings.
+func scalarString(v any) string {
+	if v == nil {
+		return "null"
+	}
+	if n, ok := v.(json.Number); ok {
+		return n.String()
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.String {
+		return rv.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// isString reports whether v is rendered as a JSON string.
+func isString(v any) bool {
+	if _, ok := v.(json.Number); ok {
+		return false
+	}
+	return v != nil && reflect.ValueOf(v).Kind() == reflect.String
+}
+
+// ----- Binary Formats -----
+
+// maxDecodeDepth bounds nesting in the binary decoders.
+const maxDecodeDepth = 512
+
+// binaryWriter is implemented by the CBOR and MessagePack encoders.
+type binaryWriter interface {
+	writeNil()
+	writeBool(b bool)
+	writeInt(n int64)
+	writeUint(n uint64)
+	writeFloat32(f float32)
+	writeFloat64(f float64)
+	writeString(s string)
+	writeArrayHeader(n int)
+	writeMapHeader(n int)
+	writeRawJSON(b []byte)
+}
+
+// writeBinary walks an output tree. Raw JSON from MarshalLog, MarshalJSON and
+// serializers is converted to native values unless embedRaw is set.
+func writeBinary(w binaryWriter, v any, embedRaw bool) error {
+	switch val := v.(type) {
+	case nil:
+		w.writeNil()
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		w.writeMapHeader(len(keys))
+		for _, k := range keys {
+			w.writeString(k)
+			if err := writeBinary(w, val[k], embedRaw); err != nil {
+				return err
+			}
+		}
+	case []any:
+		w.writeArrayHeader(len(val))
+		for _, sub := range val {
+			if err := writeBinary(w, sub, embedRaw); err != nil {
+				return err
+			}
+		}
+	case json.RawMessage:
+		if embedRaw {
+			w.writeRawJSON(val)
+			return nil
+		}
+		return writeBinary(w, plainTree(val), false)
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			w.writeInt(n)
+			return nil
+		}
+		if n, err := strconv.ParseUint(string(val), 10, 64); err == nil {
+			w.writeUint(n)
+			return nil
+		}
+		f, err := val.Float64()
+		if err != nil {
+			return fmt.Errorf("log: invalid number %q", string(val))
+		}
+		w.writeFloat64(f)
+	default:
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Bool:
+			w.writeBool(rv.Bool())
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			w.writeInt(rv.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			w.writeUint(rv.Uint())
+		case reflect.Float32:
+			w.writeFloat32(float32(rv.Float()))
+		case reflect.Float64:
+			w.writeFloat64(rv.Float())
+		case reflect.String:
+			w.writeString(rv.String())
+		default:
+			return fmt.Errorf("log: cannot encode %T", v)
+		}
+	}
+	return nil
+}
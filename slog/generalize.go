@@ -0,0 +1,182 @@
+package slog
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ----- Generalisation (Privacy-Preserving Analytics) -----
+
+// RegisterGeneralizationSerializers registers serializers that coarsen values
+// instead of hiding them:
+//
+//	ser=bucket(10)       ages 37 → "30-39", salaries 54321.5 with bucket(10000) → "[50000,60000)"
+//	ser=round_time(1h)   timestamps truncated to the hour of their own time zone (RFC3339)
+//	ser=geo(2)           coordinates rounded to 2 decimals (floats, slices, "lat,lng" strings)
+//	mask=zip3            postcodes truncated to 3 characters
+func RegisterGeneralizationSerializers() {
+	RegisterSerializerFactory("bucket", bucketSerializer)
+	RegisterSerializerFactory("round_time", roundTimeSerializer)
+	RegisterSerializerFactory("geo", geoSerializer)
+	RegisterSerializerSchema("bucket", typeSchema("string"))
+	RegisterSerializerSchema("round_time", typeSchema("string"))
+
+	RegisterMask("zip3", func(s string) string {
+		return truncateKeep(s, 3)
+	})
+}
+
+// bucketSerializer maps values to half-open ranges [lo, lo+width). Integers with an
+// integral width are labelled "lo-hi" with hi = lo+width-1, which is the same range;
+// other values use the explicit "[lo,hi)" form so that 39.5 does not read as "30-39".
+func bucketSerializer(args []string) (SerializerFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("bucket expects 1 argument, got %d", len(args))
+	}
+	width, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || width <= 0 {
+		return nil, fmt.Errorf("bucket width must be a positive number, got %q", args[0])
+	}
+	integral := width == math.Trunc(width)
+
+	return func(v any) ([]byte, error) {
+		v = derefValue(v)
+		f, err := toFloat64(v)
+		if err != nil {
+			return nil, err
+		}
+		lo := math.Floor(f/width) * width
+		if integral && isIntegerKind(reflect.ValueOf(v).Kind()) {
+			return json.Marshal(fmt.Sprintf("%d-%d", int64(lo), int64(lo+width)-1))
+		}
+		return json.Marshal(fmt.Sprintf("[%g,%g)", lo, lo+width))
+	}, nil
+}
+
+func roundTimeSerializer(args []string) (SerializerFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("round_time expects 1 argument, got %d", len(args))
+	}
+	d, err := time.ParseDuration(args[0])
+	if err != nil || d <= 0 {
+		return nil, fmt.Errorf("round_time expects a positive duration, got %q", args[0])
+	}
+
+	return func(v any) ([]byte, error) {
+		switch t := derefValue(v).(type) {
+		case time.Time:
+			return json.Marshal(truncateLocal(t, d).Format(time.RFC3339))
+		case time.Duration:
+			return json.Marshal(t.Truncate(d).String())
+		case string:
+			parsed, err := time.Parse(time.RFC3339Nano, t)
+			if err != nil {
+				return nil, fmt.Errorf("round_time: %w", err)
+			}
+			return json.Marshal(truncateLocal(parsed, d).Format(time.RFC3339))
+		}
+		return nil, fmt.Errorf("round_time serializer expects time.Time, got %T", v)
+	}, nil
+}
+
+// truncateLocal truncates t to a multiple of d in its own time zone, so that
+// hours stay whole in zones whose offset is not a whole hour (e.g. +05:30).
+func truncateLocal(t time.Time, d time.Duration) time.Time {
+	_, offset := t.Zone()
+	shift := time.Duration(offset) * time.Second
+	return t.Add(shift).Truncate(d).Add(-shift)
+}
+
+func isIntegerKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func geoSerializer(args []string) (SerializerFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("geo expects 1 argument, got %d", len(args))
+	}
+	decimals, err := strconv.Atoi(args[0])
+	if err != nil || decimals < 0 {
+		return nil, fmt.Errorf("geo expects a non-negative number of decimals, got %q", args[0])
+	}
+	multiplier := math.Pow(10, float64(decimals))
+	round := func(f float64) float64 { return math.Round(f*multiplier) / multiplier }
+
+	return func(v any) ([]byte, error) {
+		v = derefValue(v)
+		if s, ok := v.(string); ok {
+			parts := strings.Split(s, ",")
+			for i, p := range parts {
+				f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+				if err != nil {
+					return nil, fmt.Errorf("geo: %w", err)
+				}
+				parts[i] = strconv.FormatFloat(round(f), 'f', decimals, 64)
+			}
+			return json.Marshal(strings.Join(parts, ","))
+		}
+
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+			out := make([]float64, rv.Len())
+			for i := range out {
+				f, err := toFloat64(rv.Index(i).Interface())
+				if err != nil {
+					return nil, fmt.Errorf("geo: %w", err)
+				}
+				out[i] = round(f)
+			}
+			return json.Marshal(out)
+		}
+
+		f, err := toFloat64(v)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(round(f))
+	}, nil
+}
+
+// truncateKeep keeps the first n letters/digits of s and masks the remaining ones.
+func truncateKeep(s string, n int) string {
+	var b strings.Builder
+	kept := 0
+	for _, r := range s {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			b.WriteRune(r)
+		case kept < n:
+			b.WriteRune(r)
+			kept++
+		default:
+			b.WriteByte('*')
+		}
+	}
+	return b.String()
+}
+
+// derefValue unwraps pointers passed to serializers.
+func derefValue(v any) any {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	return rv.Interface()
+}
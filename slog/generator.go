@@ -214,9 +214,9 @@ func (gs *GeneratedSerializer) generateStringField(buf *strings.Builder, fieldAc
 // generateIntField generates optimized code for integer fields
 func (gs *GeneratedSerializer) generateIntField(buf *strings.Builder, fieldAccess string, fg FieldGenerator) {
 	if fg.ForceString {
-		buf.WriteString(fmt.Sprintf("\tbuf.WriteString(\"\\\"%d\\\"\")\n", fieldAccess))
+		buf.WriteString(fmt.Sprintf("\tbuf.WriteString(\"\\\"\" + strconv.FormatInt(int64(%s), 10) + \"\\\"\")\n", fieldAccess))
 	} else {
-		buf.WriteString(fmt.Sprintf("\tbuf.WriteString(strconv.FormatInt(int64(%s), 10)))\n", fieldAccess))
+		buf.WriteString(fmt.Sprintf("\tbuf.WriteString(strconv.FormatInt(int64(%s), 10))\n", fieldAccess))
 	}
 }
 
@@ -0,0 +1,205 @@
+package slog
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+)
+
+// ----- Keyed Hashing (Pseudonymisation) -----
+
+const (
+	hashTokenPrefix    = "h:"
+	defaultTokenLength = 16 // hex characters kept from the digest
+)
+
+// Hasher produces stable, truncated, prefixed tokens for the sha256 and hmac masks.
+// The same input always yields the same token for a given key, so log lines for the
+// same user can be correlated without exposing the original value.
+//
+// Token format: "h:<keyID>:<digest>". Without a key both masks fall back to the
+// default mask, since an unsalted digest of low-entropy values such as emails or
+// phone numbers can be reversed by brute force. Older keys can be kept around
+// with AddKey so that tokens issued before a rotation can still be verified.
+type Hasher struct {
+	mu       sync.RWMutex
+	keys     map[string][]byte
+	activeID string
+	length   int
+}
+
+// NewHasher creates a hasher whose active key is (id, secret).
+// An empty id or secret creates a hasher without a key.
+func NewHasher(id string, secret []byte) *Hasher {
+	h := &Hasher{
+		keys:   make(map[string][]byte),
+		length: defaultTokenLength,
+	}
+	if id != "" && len(secret) > 0 {
+		h.keys[id] = append([]byte(nil), secret...)
+		h.activeID = id
+	}
+	return h
+}
+
+// AddKey registers an additional (usually retired) key used only for verification.
+func (h *Hasher) AddKey(id string, secret []byte) {
+	if id == "" || len(secret) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.keys[id] = append([]byte(nil), secret...)
+}
+
+// Rotate registers (id, secret) and makes it the active key for new tokens.
+func (h *Hasher) Rotate(id string, secret []byte) {
+	if id == "" || len(secret) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.keys[id] = append([]byte(nil), secret...)
+	h.activeID = id
+}
+
+// SetTokenLength sets the number of hex digest characters kept in tokens (1-64).
+func (h *Hasher) SetTokenLength(n int) {
+	if n <= 0 || n > sha256.Size*2 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.length = n
+}
+
+// KeyID returns the ID of the active key, or "" if the hasher has no key.
+func (h *Hasher) KeyID() string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.activeID
+}
+
+// SHA256 returns a sha256 token, the digest being salted with the active key.
+// Without a key, the value falls back to the default mask so it is never exposed.
+func (h *Hasher) SHA256(s string) string {
+	h.mu.RLock()
+	id, secret, n := h.activeID, h.keys[h.activeID], h.length
+	h.mu.RUnlock()
+	if id == "" {
+		return defaultMask(s)
+	}
+	return formatHashToken(id, sha256Digest(secret, s), n)
+}
+
+// HMAC returns an HMAC-SHA256 token using the active key.
+// Without a key, the value falls back to the default mask so it is never exposed.
+func (h *Hasher) HMAC(s string) string {
+	h.mu.RLock()
+	id, secret, n := h.activeID, h.keys[h.activeID], h.length
+	h.mu.RUnlock()
+	if id == "" {
+		return defaultMask(s)
+	}
+	return formatHashToken(id, hmacDigest(secret, s), n)
+}
+
+// Verify reports whether token was produced for value by this hasher, using the
+// key ID embedded in the token. Both sha256 and hmac tokens are accepted. The
+// digest must have the configured token length or be the full digest, so that
+// shortened tokens cannot match by chance.
+func (h *Hasher) Verify(token, value string) bool {
+	id, digest, ok := ParseHashToken(token)
+	if !ok {
+		return false
+	}
+	h.mu.RLock()
+	secret, known := h.keys[id]
+	n := h.length
+	h.mu.RUnlock()
+	if !known || (len(digest) != n && len(digest) != sha256.Size*2) {
+		return false
+	}
+
+	for _, c := range []string{sha256Digest(secret, value), hmacDigest(secret, value)} {
+		if hmac.Equal([]byte(c[:len(digest)]), []byte(digest)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseHashToken splits a token into its key ID and digest. The key ID is empty
+// for tokens without one, which Verify rejects.
+func ParseHashToken(token string) (keyID, digest string, ok bool) {
+	if !strings.HasPrefix(token, hashTokenPrefix) {
+		return "", "", false
+	}
+	rest := token[len(hashTokenPrefix):]
+	if i := strings.LastIndexByte(rest, ':'); i >= 0 {
+		keyID, digest = rest[:i], rest[i+1:]
+	} else {
+		digest = rest
+	}
+	if digest == "" {
+		return "", "", false
+	}
+	if _, err := hex.DecodeString(digest + strings.Repeat("0", len(digest)%2)); err != nil {
+		return "", "", false
+	}
+	return keyID, digest, true
+}
+
+// mask returns the MaskFunc for a hash mask name, or nil if name is not a hash mask.
+func (h *Hasher) mask(name string) MaskFunc {
+	switch name {
+	case "sha256":
+		return h.SHA256
+	case "hmac":
+		return h.HMAC
+	}
+	return nil
+}
+
+func sha256Digest(secret []byte, s string) string {
+	hh := sha256.New()
+	if len(secret) > 0 {
+		hh.Write(secret)
+		hh.Write([]byte{0})
+	}
+	hh.Write([]byte(s))
+	return hex.EncodeToString(hh.Sum(nil))
+}
+
+func hmacDigest(secret []byte, s string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(s))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func formatHashToken(keyID, digest string, n int) string {
+	if n > 0 && n < len(digest) {
+		digest = digest[:n]
+	}
+	if keyID == "" {
+		return hashTokenPrefix + digest
+	}
+	return hashTokenPrefix + keyID + ":" + digest
+}
+
+// ----- Process-wide Hasher -----
+
+var defaultHasher = NewHasher("", nil)
+
+// SetHashKey sets the process-wide key used by the sha256 and hmac masks.
+// The previous key is retained for verification, which allows key rotation.
+func SetHashKey(id string, secret []byte) {
+	defaultHasher.Rotate(id, secret)
+}
+
+// DefaultHasher returns the process-wide hasher used when Options.Hasher is nil.
+func DefaultHasher() *Hasher {
+	return defaultHasher
+}
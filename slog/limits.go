@@ -0,0 +1,280 @@
+package slog
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ----- Size Limits -----
+
+const (
+	truncatedKey     = "_truncated"
+	maxDepthMarker   = "...(max depth)"
+	moreItemsKey     = "..."
+	truncateSuffix   = "..."
+	truncatedReserve = 96 // bytes kept free for the _truncated meta field
+)
+
+// truncation counts how often each limit was hit during one Marshal.
+type truncation struct {
+	depth   int
+	items   int
+	strings int
+	bytes   int
+}
+
+func (t *truncation) any() bool {
+	return t.depth+t.items+t.strings+t.bytes > 0
+}
+
+// meta returns the _truncated field value, listing only limits that were hit.
+func (t *truncation) meta() map[string]any {
+	m := make(map[string]any)
+	if t.depth > 0 {
+		m["depth"] = t.depth
+	}
+	if t.items > 0 {
+		m["items"] = t.items
+	}
+	if t.strings > 0 {
+		m["strings"] = t.strings
+	}
+	if t.bytes > 0 {
+		m["bytes"] = t.bytes
+	}
+	return m
+}
+
+// atMaxDepth reports whether a container at e's depth exceeds Options.MaxDepth.
+func (e *encoder) atMaxDepth() bool {
+	if e.opts.MaxDepth <= 0 || e.depth < e.opts.MaxDepth {
+		return false
+	}
+	if e.state != nil {
+		e.state.trunc.depth++
+	}
+	return true
+}
+
+// itemLimit returns how many of n collection items are serialized.
+func (e *encoder) itemLimit(n int) int {
+	if e.opts.MaxItems <= 0 || n <= e.opts.MaxItems {
+		return n
+	}
+	if e.state != nil {
+		e.state.trunc.items++
+	}
+	return e.opts.MaxItems
+}
+
+// moreItems returns the marker appended to a truncated collection.
+func moreItems(n int) string {
+	return "...(+" + strconv.Itoa(n) + " more)"
+}
+
+// sortedMapKeys returns the string keys of a map in sorted order.
+func sortedMapKeys(rv reflect.Value) []reflect.Value {
+	keys := rv.MapKeys()
+	sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+	return keys
+}
+
+// limitStrings applies Options.MaxStringLen to the string values of the output.
+// It runs after masking and redaction, so that masks, hashes and scrub rules see
+// whole values. Limit markers, $ref pointers and error fallbacks are kept whole;
+// serializer and MarshalLog output is left as produced.
+func (e *encoder) limitStrings(v any) any {
+	switch val := v.(type) {
+	case string:
+		if val == maxDepthMarker || isMoreItems(val) {
+			return val
+		}
+		s, cut := truncateRunes(val, e.opts.MaxStringLen)
+		if cut {
+			e.state.trunc.strings++
+		}
+		return s
+	case map[string]any:
+		for k, sub := range val {
+			if k != refKey && k != errorKey {
+				val[k] = e.limitStrings(sub)
+			}
+		}
+	case []any:
+		for i, sub := range val {
+			val[i] = e.limitStrings(sub)
+		}
+	}
+	return v
+}
+
+// isMoreItems reports whether s is a marker returned by moreItems.
+func isMoreItems(s string) bool {
+	return strings.HasPrefix(s, "...(+") && strings.HasSuffix(s, " more)")
+}
+
+// truncateRunes shortens s to at most max runes, adding a suffix if cut.
+func truncateRunes(s string, max int) (string, bool) {
+	if utf8.RuneCountInString(s) <= max {
+		return s, false
+	}
+	i, n := 0, 0
+	for i < len(s) && n < max {
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return s[:i] + truncateSuffix, true
+}
+
+// applyLimits enforces Options.MaxStringLen and Options.MaxBytes on the encoded
+// output and adds the _truncated meta field to object records when any limit was hit.
+func (e *encoder) applyLimits() {
+	if e.state == nil || e.out == nil {
+		return
+	}
+	if e.opts.MaxStringLen > 0 {
+		e.out = e.limitStrings(e.out)
+	}
+	if max := e.opts.MaxBytes; max > 0 && jsonSize(e.out) > max {
+		budget := max
+		if _, ok := e.out.(map[string]any); ok {
+			budget -= truncatedReserve
+		}
+		out, _ := pruneToBudget(e.out, budget, &e.state.trunc)
+		if out == nil {
+			out = map[string]any{}
+		}
+		e.out = out
+		if e.state.hasRefs {
+			e.out = e.dropDanglingRefs(e.out)
+		}
+	}
+	if m, ok := e.out.(map[string]any); ok && e.state.trunc.any() {
+		m[truncatedKey] = e.state.trunc.meta()
+	}
+}
+
+// jsonSize returns the compact JSON size of an encoded value.
+func jsonSize(v any) int {
+	switch val := v.(type) {
+	case nil:
+		return 4
+	case map[string]any:
+		n := 2
+		i := 0
+		for k, sub := range val {
+			if i > 0 {
+				n++
+			}
+			n += jsonSize(k) + 1 + jsonSize(sub)
+			i++
+		}
+		return n
+	case []any:
+		n := 2
+		for i, sub := range val {
+			if i > 0 {
+				n++
+			}
+			n += jsonSize(sub)
+		}
+		return n
+	case json.RawMessage:
+		return len(val)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return len(fmt.Sprint(v))
+	}
+	return len(b)
+}
+
+// pruneToBudget keeps as much of v as fits in budget bytes, visiting object keys
+// in output order. Values that do not fit are dropped (strings are shortened)
+// and counted in t. Containers are filled item by item, so every value is
+// measured once. Arrays keep a prefix of their items and $ref objects are kept
+// whole, so that the pointers which survive stay valid. It returns nil if
+// nothing fits.
+func pruneToBudget(v any, budget int, t *truncation) (any, int) {
+	if m, ok := v.(map[string]any); ok {
+		if _, isRef := refPointer(m); isRef {
+			if n := jsonSize(m); n <= budget {
+				return m, n
+			}
+			t.bytes++
+			return nil, 0
+		}
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		if budget < 2 {
+			t.bytes++
+			return nil, 0
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make(map[string]any, len(val))
+		used := 2
+		for _, k := range keys {
+			overhead := jsonSize(k) + 1
+			if len(out) > 0 {
+				overhead++
+			}
+			sub, n := pruneToBudget(val[k], budget-used-overhead, t)
+			if sub == nil && (val[k] != nil || n == 0) {
+				continue
+			}
+			out[k] = sub
+			used += overhead + n
+		}
+		return out, used
+	case []any:
+		if budget < 2 {
+			t.bytes++
+			return nil, 0
+		}
+		out := make([]any, 0, len(val))
+		used := 2
+		for i, sub := range val {
+			overhead := 0
+			if len(out) > 0 {
+				overhead = 1
+			}
+			pruned, n := pruneToBudget(sub, budget-used-overhead, t)
+			if pruned == nil {
+				t.bytes += len(val) - i - 1
+				break
+			}
+			out = append(out, pruned)
+			used += overhead + n
+		}
+		return out, used
+	case string:
+		if n := jsonSize(val); n <= budget {
+			return val, n
+		}
+		// Shorten to fit: quotes and suffix need room, escapes are accounted for by re-measuring
+		for max := budget - 2 - len(truncateSuffix); max > 0; max = max * 3 / 4 {
+			s, _ := truncateRunes(val, max)
+			if n := jsonSize(s); n <= budget {
+				t.bytes++
+				return s, n
+			}
+		}
+	default:
+		if n := jsonSize(v); n <= budget {
+			return v, n
+		}
+	}
+	t.bytes++
+	return nil, 0
+}
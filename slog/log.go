@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"reflect"
 	"strings"
 	"sync"
 	"time"
@@ -38,9 +37,44 @@ func Marshal(v any) ([]byte, error) {
 
 // MarshalWithOpts serializes the value with custom options.
 func MarshalWithOpts(v any, opts ...Option) ([]byte, error) {
+	b, _, err := marshal(v, opts)
+	return b, err
+}
+
+// MarshalReport serializes the value like MarshalWithOpts and also returns the
+// fields that failed and were replaced by an error fallback object.
+func MarshalReport(v any, opts ...Option) ([]byte, []FieldError, error) {
+	return marshal(v, opts)
+}
+
+func marshal(v any, opts []Option) ([]byte, []FieldError, error) {
+	return render(v, opts, writeOutput)
+}
+
+// render encodes v and passes the finished output tree to write before the encoder is released.
+func render(v any, opts []Option, write func(out any, o *Options) ([]byte, error)) ([]byte, []FieldError, error) {
 	enc := newEncoder()
 	defer releaseEncoder(enc)
 
+	options := newOptions(opts)
+	enc.opts = options
+
+	if err := enc.encode(v); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.addFields(); err != nil {
+		return nil, nil, err
+	}
+	enc.resolveRefs()
+	enc.redactOutput()
+	enc.applyLimits()
+
+	b, err := write(enc.out, options)
+	return b, enc.state.errs, err
+}
+
+// newOptions applies opts to the default options.
+func newOptions(opts []Option) *Options {
 	options := &Options{
 		MaskSensitive:       false,
 		EnableErrorFallback: true,
@@ -49,23 +83,26 @@ func MarshalWithOpts(v any, opts ...Option) ([]byte, error) {
 	for _, opt := range opts {
 		opt(options)
 	}
-	enc.opts = options
+	return options
+}
 
-	if err := enc.encode(v); err != nil {
-		return nil, err
+// writeOutput renders the output tree in Options.Format.
+func writeOutput(out any, o *Options) ([]byte, error) {
+	if o.Format != FormatJSON {
+		return encodeFormat(o, out)
 	}
 
-	if enc.out == nil {
+	if out == nil {
 		return []byte("null"), nil
 	}
 
 	var buf bytes.Buffer
 	jsonEnc := json.NewEncoder(&buf)
-	if options.Indent != "" {
-		jsonEnc.SetIndent(options.Prefix, options.Indent)
+	if o.Indent != "" {
+		jsonEnc.SetIndent(o.Prefix, o.Indent)
 	}
 
-	if err := jsonEnc.Encode(enc.out); err != nil {
+	if err := jsonEnc.Encode(out); err != nil {
 		return nil, err
 	}
 
@@ -84,17 +121,9 @@ func MarshalWithContext(ctx context.Context, v any, opts ...Option) ([]byte, err
 		return MarshalWithOpts(v, opts...)
 	}
 
-	// Extract trace information from context if available
-	if traceID := ctx.Value("trace_id"); traceID != nil {
-		if traceStr, ok := traceID.(string); ok {
-			// Add trace ID to the options or handle it appropriately
-			opts = append(opts, func(o *Options) {
-				// Store trace ID in options for potential use by serializers
-				if o.Prefix == "" {
-					o.Prefix = traceStr
-				}
-			})
-		}
+	// Context fields (trace_id, tenant, ...) become top-level fields of the record
+	if fields := ContextFields(ctx); len(fields) > 0 {
+		opts = append(opts, WithFields(fields...))
 	}
 
 	return MarshalWithOpts(v, opts...)
@@ -168,14 +197,14 @@ var (
 	encoderPool = sync.Pool{
 		New: func() interface{} {
 			return &encoder{
-				visited: make(map[uintptr]bool),
+				visited: make(map[visitKey]bool),
 			}
 		},
 	}
 
 	// Struct info cache
 	fieldCache = &structCache{
-		m: make(map[reflect.Type]*structInfo),
+		m: make(map[structKey]*structInfo),
 	}
 )
 
@@ -197,11 +226,15 @@ func init() {
 		}
 		return "***"
 	})
+	RegisterMask("sha256", func(s string) string { return defaultHasher.SHA256(s) })
+	RegisterMask("hmac", func(s string) string { return defaultHasher.HMAC(s) })
+	RegisterMask("encrypt", func(s string) string { return encryptMask(getKeyProvider())(s) })
 
 	// Register default serializers with lazy loading
 	RegisterCurrencyFormattedSerializer()
 	RegisterTimeFormattedSerializer()
 	RegisterDurationFormattedSerializer()
+	RegisterGeneralizationSerializers()
 
 	// Register custom time serializers (these are simple, keep as immediate)
 	RegisterTimeSerializerWithLayout("time_short_date", "2006-01-02")
@@ -0,0 +1,148 @@
+package slog
+
+import (
+	"bytes"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// ----- logfmt Output -----
+
+// logfmtRootKey names the value of a record that is not an object.
+const logfmtRootKey = "value"
+
+// encodeLogfmt renders an encoded record as logfmt. Nested objects and arrays
+// are flattened to dotted keys ("user.address.city", "items.0.sku") in sorted
+// order; empty containers are written as {} and [].
+func encodeLogfmt(v any) []byte {
+	var buf bytes.Buffer
+	v = plainTree(v)
+	switch v.(type) {
+	case nil:
+		return buf.Bytes()
+	case map[string]any, []any:
+		writeLogfmt(&buf, "", v)
+	default:
+		writeLogfmt(&buf, logfmtRootKey, v)
+	}
+	return buf.Bytes()
+}
+
+func writeLogfmt(buf *bytes.Buffer, key string, v any) {
+	switch val := v.(type) {
+	case map[string]any:
+		if len(val) == 0 && key != "" {
+			writeLogfmtPair(buf, key, "{}", false)
+			return
+		}
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			writeLogfmt(buf, joinKey(key, k), val[k])
+		}
+	case []any:
+		if len(val) == 0 && key != "" {
+			writeLogfmtPair(buf, key, "[]", false)
+			return
+		}
+		for i, sub := range val {
+			writeLogfmt(buf, joinKey(key, strconv.Itoa(i)), sub)
+		}
+	default:
+		writeLogfmtPair(buf, key, scalarString(val), isString(val))
+	}
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func writeLogfmtPair(buf *bytes.Buffer, key, value string, isString bool) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	writeLogfmtKey(buf, key)
+	buf.WriteByte('=')
+	// Strings that would read back as another type or as empty are quoted
+	if needsQuote(value) || (isString && readsAsOther(value)) {
+		writeQuoted(buf, value)
+		return
+	}
+	buf.WriteString(value)
+}
+
+// writeLogfmtKey writes key, replacing characters that logfmt keys cannot contain.
+func writeLogfmtKey(buf *bytes.Buffer, key string) {
+	if key == "" {
+		buf.WriteByte('_')
+		return
+	}
+	for _, r := range key {
+		if r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f {
+			buf.WriteByte('_')
+			continue
+		}
+		buf.WriteRune(r)
+	}
+}
+
+// readsAsOther reports whether an unquoted string would parse as empty, null,
+// a bool or a number.
+func readsAsOther(s string) bool {
+	switch s {
+	case "", "null", "true", "false":
+		return true
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func needsQuote(s string) bool {
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || r == utf8.RuneError || r == 0x7f {
+			return true
+		}
+	}
+	return false
+}
+
+// writeQuoted writes s as a double-quoted string using the JSON-style escapes
+// understood by common logfmt parsers.
+func writeQuoted(buf *bytes.Buffer, s string) {
+	buf.WriteByte('"')
+	for _, r := range s {
+		if r == '"' || r == '\\' {
+			buf.WriteByte('\\')
+			buf.WriteRune(r)
+			continue
+		}
+		writeEscapedRune(buf, r)
+	}
+	buf.WriteByte('"')
+}
+
+// writeEscapedRune writes r, escaping control characters.
+func writeEscapedRune(buf *bytes.Buffer, r rune) {
+	const hex = "0123456789abcdef"
+	switch {
+	case r == '\n':
+		buf.WriteString(`\n`)
+	case r == '\r':
+		buf.WriteString(`\r`)
+	case r == '\t':
+		buf.WriteString(`\t`)
+	case r < ' ' || r == 0x7f:
+		buf.WriteString(`\u00`)
+		buf.WriteByte(hex[r>>4])
+		buf.WriteByte(hex[r&0xf])
+	default:
+		buf.WriteRune(r)
+	}
+}
@@ -0,0 +1,305 @@
+package slog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"unicode/utf8"
+)
+
+// ----- MessagePack -----
+
+const (
+	mpNil     = 0xc0
+	mpFalse   = 0xc2
+	mpTrue    = 0xc3
+	mpBin8    = 0xc4
+	mpBin16   = 0xc5
+	mpBin32   = 0xc6
+	mpFloat32 = 0xca
+	mpFloat64 = 0xcb
+	mpUint8   = 0xcc
+	mpUint16  = 0xcd
+	mpUint32  = 0xce
+	mpUint64  = 0xcf
+	mpInt8    = 0xd0
+	mpInt16   = 0xd1
+	mpInt32   = 0xd2
+	mpInt64   = 0xd3
+	mpStr8    = 0xd9
+	mpStr16   = 0xda
+	mpStr32   = 0xdb
+	mpArray16 = 0xdc
+	mpArray32 = 0xdd
+	mpMap16   = 0xde
+	mpMap32   = 0xdf
+)
+
+var errMsgpackTruncated = errors.New("log: msgpack: unexpected end of data")
+
+type msgpackWriter struct {
+	buf []byte
+}
+
+func (w *msgpackWriter) writeNil() { w.buf = append(w.buf, mpNil) }
+
+func (w *msgpackWriter) writeBool(b bool) {
+	if b {
+		w.buf = append(w.buf, mpTrue)
+		return
+	}
+	w.buf = append(w.buf, mpFalse)
+}
+
+func (w *msgpackWriter) writeInt(n int64) {
+	switch {
+	case n >= 0:
+		w.writeUint(uint64(n))
+	case n >= -32:
+		w.buf = append(w.buf, byte(n))
+	case n >= math.MinInt8:
+		w.buf = append(w.buf, mpInt8, byte(n))
+	case n >= math.MinInt16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, mpInt16), uint16(n))
+	case n >= math.MinInt32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, mpInt32), uint32(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, mpInt64), uint64(n))
+	}
+}
+
+func (w *msgpackWriter) writeUint(n uint64) {
+	switch {
+	case n <= 0x7f:
+		w.buf = append(w.buf, byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, mpUint8, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, mpUint16), uint16(n))
+	case n <= math.MaxUint32:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, mpUint32), uint32(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint64(append(w.buf, mpUint64), n)
+	}
+}
+
+func (w *msgpackWriter) writeFloat32(f float32) {
+	w.buf = binary.BigEndian.AppendUint32(append(w.buf, mpFloat32), math.Float32bits(f))
+}
+
+func (w *msgpackWriter) writeFloat64(f float64) {
+	w.buf = binary.BigEndian.AppendUint64(append(w.buf, mpFloat64), math.Float64bits(f))
+}
+
+func (w *msgpackWriter) writeString(s string) {
+	n := len(s)
+	switch {
+	case n < 32:
+		w.buf = append(w.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, mpStr8, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, mpStr16), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, mpStr32), uint32(n))
+	}
+	w.buf = append(w.buf, s...)
+}
+
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, mpArray16), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, mpArray32), uint32(n))
+	}
+}
+
+func (w *msgpackWriter) writeMapHeader(n int) {
+	switch {
+	case n < 16:
+		w.buf = append(w.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = binary.BigEndian.AppendUint16(append(w.buf, mpMap16), uint16(n))
+	default:
+		w.buf = binary.BigEndian.AppendUint32(append(w.buf, mpMap32), uint32(n))
+	}
+}
+
+// writeRawJSON embeds JSON as a string, MessagePack having no standard JSON type.
+func (w *msgpackWriter) writeRawJSON(b []byte) { w.writeString(string(b)) }
+
+// DecodeMsgpack decodes a MessagePack value into nil, bool, int64, uint64
+// (above MaxInt64), float64, string, []byte, []any or map[string]any.
+// Extension types are not supported.
+func DecodeMsgpack(data []byte) (any, error) {
+	d := &msgpackDecoder{data: data}
+	v, err := d.value(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.pos != len(d.data) {
+		return nil, fmt.Errorf("log: msgpack: %d trailing bytes", len(d.data)-d.pos)
+	}
+	return v, nil
+}
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) next(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.pos) {
+		return nil, errMsgpackTruncated
+	}
+	b := d.data[d.pos : d.pos+int(n)]
+	d.pos += int(n)
+	return b, nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes.
+func (d *msgpackDecoder) uint(size int) (uint64, error) {
+	b, err := d.next(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+	switch size {
+	case 1:
+		return uint64(b[0]), nil
+	case 2:
+		return uint64(binary.BigEndian.Uint16(b)), nil
+	case 4:
+		return uint64(binary.BigEndian.Uint32(b)), nil
+	}
+	return binary.BigEndian.Uint64(b), nil
+}
+
+func (d *msgpackDecoder) value(depth int) (any, error) {
+	if depth > maxDecodeDepth {
+		return nil, errors.New("log: msgpack: nesting too deep")
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+
+	switch {
+	case c <= 0x7f:
+		return int64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xf0 == 0x80:
+		return d.mapOf(uint64(c&0x0f), depth)
+	case c&0xf0 == 0x90:
+		return d.arrayOf(uint64(c&0x0f), depth)
+	case c&0xe0 == 0xa0:
+		return d.str(uint64(c & 0x1f))
+	}
+
+	switch c {
+	case mpNil:
+		return nil, nil
+	case mpFalse:
+		return false, nil
+	case mpTrue:
+		return true, nil
+	case mpFloat32:
+		n, err := d.uint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case mpFloat64:
+		n, err := d.uint(8)
+		return math.Float64frombits(n), err
+	case mpUint8, mpUint16, mpUint32, mpUint64:
+		n, err := d.uint(1 << (c - mpUint8))
+		if err != nil || n > math.MaxInt64 {
+			return n, err
+		}
+		return int64(n), nil
+	case mpInt8:
+		n, err := d.uint(1)
+		return int64(int8(n)), err
+	case mpInt16:
+		n, err := d.uint(2)
+		return int64(int16(n)), err
+	case mpInt32:
+		n, err := d.uint(4)
+		return int64(int32(n)), err
+	case mpInt64:
+		n, err := d.uint(8)
+		return int64(n), err
+	case mpStr8, mpStr16, mpStr32:
+		n, err := d.uint(1 << (c - mpStr8))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case mpBin8, mpBin16, mpBin32:
+		n, err := d.uint(1 << (c - mpBin8))
+		if err != nil {
+			return nil, err
+		}
+		bin, err := d.next(n)
+		return append([]byte(nil), bin...), err
+	case mpArray16, mpArray32:
+		n, err := d.uint(2 << (c - mpArray16))
+		if err != nil {
+			return nil, err
+		}
+		return d.arrayOf(n, depth)
+	case mpMap16, mpMap32:
+		n, err := d.uint(2 << (c - mpMap16))
+		if err != nil {
+			return nil, err
+		}
+		return d.mapOf(n, depth)
+	}
+	return nil, fmt.Errorf("log: msgpack: unsupported type byte 0x%02x", c)
+}
+
+func (d *msgpackDecoder) str(n uint64) (any, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	if !utf8.Valid(b) {
+		return nil, errors.New("log: msgpack: invalid UTF-8 in string")
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) arrayOf(n uint64, depth int) (any, error) {
+	arr := make([]any, 0, min(n, 1024))
+	for i := uint64(0); i < n; i++ {
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+func (d *msgpackDecoder) mapOf(n uint64, depth int) (any, error) {
+	m := make(map[string]any, min(n, 1024))
+	for i := uint64(0); i < n; i++ {
+		k, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+		m[key] = v
+	}
+	return m, nil
+}
@@ -5,6 +5,7 @@ package slog
 import (
 	"fmt"
 	"reflect"
+	"strings"
 )
 
 // ----- Error Type -----
@@ -12,14 +13,35 @@ import (
 type MarshalError struct {
 	Type  reflect.Type
 	Field string
+	Path  string // dotted path of the failing value, e.g. "items[3].product.price"; empty at the root
 	Err   error
+
+	segs []string // path segments, kept for Pointer since keys may contain "." or "["
+}
+
+// newMarshalError returns a MarshalError for the value at path segs.
+func newMarshalError(t reflect.Type, field string, segs []string, err error) *MarshalError {
+	segs = append([]string(nil), segs...)
+	return &MarshalError{Type: t, Field: field, Path: pathString(segs), Err: err, segs: segs}
 }
 
 func (e *MarshalError) Error() string {
+	at := ""
+	if e.Path != "" {
+		at = " at " + e.Path
+	}
 	if e.Field != "" {
-		return fmt.Sprintf("log: marshal field %s of type %s: %v", e.Field, e.Type, e.Err)
+		return fmt.Sprintf("log: marshal field %s of type %s%s: %v", e.Field, e.Type, at, e.Err)
+	}
+	return fmt.Sprintf("log: marshal type %s%s: %v", e.Type, at, e.Err)
+}
+
+// Pointer returns Path as a JSON Pointer fragment, e.g. "#/items/3/product/price".
+func (e *MarshalError) Pointer() string {
+	if e.segs == nil {
+		return jsonPointer(splitPattern(e.Path))
 	}
-	return fmt.Sprintf("log: marshal type %s: %v", e.Type, e.Err)
+	return jsonPointer(e.segs)
 }
 
 func (e *MarshalError) Unwrap() error {
@@ -39,15 +61,60 @@ const (
 	FATAL
 )
 
+var levelNames = [...]string{"DEBUG", "INFO", "WARN", "ERROR", "FATAL"}
+
+func (l LogLevel) String() string {
+	if l >= DEBUG && int(l) < len(levelNames) {
+		return levelNames[l]
+	}
+	return fmt.Sprintf("LEVEL(%d)", int(l))
+}
+
+// ParseLogLevel parses a level name such as "debug" or "WARN".
+func ParseLogLevel(s string) (LogLevel, bool) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG":
+		return DEBUG, true
+	case "INFO":
+		return INFO, true
+	case "WARN", "WARNING":
+		return WARN, true
+	case "ERROR":
+		return ERROR, true
+	case "FATAL":
+		return FATAL, true
+	}
+	return INFO, false
+}
+
 type Options struct {
-	DisableLoggerInterface bool     // Disable SLogger interface
-	DisableJSONFallback    bool     // Disable JSON fallback
-	OmitEmptyByDefault     bool     // Omit empty values by default
-	MaskSensitive          bool     // Mask sensitive fields automatically
-	Indent                 string   // JSON indent
-	Prefix                 string   // JSON prefix
-	EnableErrorFallback    bool     // Enable error fallback, output error info on serialization failure
-	Level                  LogLevel // Log level for filtering
+	DisableLoggerInterface bool               // Disable SLogger interface
+	DisableJSONFallback    bool               // Disable JSON fallback
+	OmitEmptyByDefault     bool               // Omit empty values by default
+	MaskSensitive          bool               // Mask sensitive fields automatically
+	Indent                 string             // JSON indent
+	Prefix                 string             // JSON prefix
+	EnableErrorFallback    bool               // Enable error fallback, output error info on serialization failure
+	Level                  LogLevel           // Log level for filtering
+	Hasher                 *Hasher            // Hasher for sha256/hmac masks (nil uses the process-wide hasher)
+	KeyProvider            KeyProvider        // Key provider for the encrypt mask (nil uses the process-wide provider)
+	ClassPolicy            ClassPolicy        // Per-sink actions for class-tagged fields
+	Policy                 PolicySource       // Redaction rules merged with tag options (e.g. a *PolicyWatcher)
+	SensitiveDetector      *SensitiveDetector // Key/value heuristics for MaskSensitive (nil uses the process-wide detector)
+	SecretScanner          *SecretScanner     // Redact token formats and high-entropy substrings in all strings
+	Scrubber               *Scrubber          // Regex scrub rules applied to all strings, after global rules
+	View                   string             // Named view selecting slog.<view> tags and view= fields
+	Include                []string           // Only serialize these paths (e.g. "id", "items[].sku")
+	Exclude                []string           // Never serialize these paths or their subtrees
+	MaxDepth               int                // Replace containers nested deeper than this (0: unlimited)
+	MaxItems               int                // Keep at most this many slice/map items (0: unlimited)
+	MaxStringLen           int                // Truncate strings to this many runes (0: unlimited)
+	MaxBytes               int                // Prune the record to this compact JSON size (0: unlimited); approximate for Indent and non-JSON formats
+	RefMode                RefMode            // Encode cycles (and optionally shared pointers) as $ref markers
+	Fields                 []Field            // Extra top-level fields, e.g. from ContextFields
+	Format                 Format             // Output format, JSON by default
+	EmbedRawJSON           bool               // CBOR/MessagePack: embed MarshalLog and serializer JSON instead of converting it
+	DisableCSVFormulaGuard bool               // MarshalCSV/TSV: write cells starting with = + - @ as they are
 }
 
 type Option func(*Options)
@@ -74,3 +141,77 @@ func WithErrorFallback(enable bool) Option {
 func WithLevel(level LogLevel) Option {
 	return func(o *Options) { o.Level = level }
 }
+
+func WithHasher(h *Hasher) Option {
+	return func(o *Options) { o.Hasher = h }
+}
+
+func WithKeyProvider(kp KeyProvider) Option {
+	return func(o *Options) { o.KeyProvider = kp }
+}
+
+func WithClassPolicy(p ClassPolicy) Option {
+	return func(o *Options) { o.ClassPolicy = p }
+}
+
+func WithPolicy(p PolicySource) Option {
+	return func(o *Options) { o.Policy = p }
+}
+
+func WithSensitiveDetector(d *SensitiveDetector) Option {
+	return func(o *Options) { o.SensitiveDetector = d }
+}
+
+func WithSecretScanner(s *SecretScanner) Option {
+	return func(o *Options) { o.SecretScanner = s }
+}
+
+func WithScrubber(s *Scrubber) Option {
+	return func(o *Options) { o.Scrubber = s }
+}
+
+func WithView(view string) Option {
+	return func(o *Options) { o.View = view }
+}
+
+func WithInclude(paths ...string) Option {
+	return func(o *Options) { o.Include = append(o.Include, paths...) }
+}
+
+func WithExclude(paths ...string) Option {
+	return func(o *Options) { o.Exclude = append(o.Exclude, paths...) }
+}
+
+// WithLimits sets size limits; hitting any of them is reported in a _truncated field.
+// Strings are shortened after masking and redaction. maxBytes is measured as
+// compact JSON, so indented or non-JSON output can differ in size.
+func WithLimits(maxDepth, maxItems, maxStringLen, maxBytes int) Option {
+	return func(o *Options) {
+		o.MaxDepth = maxDepth
+		o.MaxItems = maxItems
+		o.MaxStringLen = maxStringLen
+		o.MaxBytes = maxBytes
+	}
+}
+
+func WithRefMode(mode RefMode) Option {
+	return func(o *Options) { o.RefMode = mode }
+}
+
+func WithFields(fields ...Field) Option {
+	return func(o *Options) { o.Fields = append(o.Fields, fields...) }
+}
+
+func WithFormat(f Format) Option {
+	return func(o *Options) { o.Format = f }
+}
+
+func WithEmbedRawJSON(embed bool) Option {
+	return func(o *Options) { o.EmbedRawJSON = embed }
+}
+
+// WithCSVFormulaGuard controls whether MarshalCSV and MarshalTSV prefix text cells
+// that a spreadsheet would run as a formula with a single quote (on by default).
+func WithCSVFormulaGuard(enable bool) Option {
+	return func(o *Options) { o.DisableCSVFormulaGuard = !enable }
+}
@@ -0,0 +1,320 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ----- Redaction Policy -----
+
+// PolicyRule maps a field path to a mask, a serializer or omission.
+//
+// Path forms:
+//   - type-qualified field: "github.com/x/pb.User.Email" or "pb.User.Email"
+//   - path from the root: "payload.raw", "orders[].card", "items[0].sku"
+//   - "*" matches any number of segments: "*.password", "user.*.token"
+//   - "[]" matches any collection index
+//
+// Segments are matched case-insensitively against the output field name.
+type PolicyRule struct {
+	Path  string `json:"path" yaml:"path"`
+	Mask  string `json:"mask,omitempty" yaml:"mask,omitempty"`
+	Ser   string `json:"ser,omitempty" yaml:"ser,omitempty"`
+	Class string `json:"class,omitempty" yaml:"class,omitempty"`
+	Omit  bool   `json:"omit,omitempty" yaml:"omit,omitempty"`
+}
+
+// RedactionPolicy is a compiled, immutable set of rules.
+// The first matching rule applies; its settings override the field's tag options.
+type RedactionPolicy struct {
+	Rules    []PolicyRule `json:"rules" yaml:"rules"`
+	compiled []compiledRule
+}
+
+type compiledRule struct {
+	rule PolicyRule
+	segs []string
+}
+
+// PolicySource supplies the current policy. *RedactionPolicy and *PolicyWatcher implement it.
+type PolicySource interface {
+	Policy() *RedactionPolicy
+}
+
+// NewRedactionPolicy compiles rules into a policy.
+func NewRedactionPolicy(rules ...PolicyRule) (*RedactionPolicy, error) {
+	p := &RedactionPolicy{Rules: rules}
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ParsePolicy parses a JSON or YAML policy document.
+func ParsePolicy(data []byte) (*RedactionPolicy, error) {
+	p := &RedactionPolicy{}
+	trimmed := bytes.TrimSpace(data)
+	var err error
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		err = json.Unmarshal(trimmed, p)
+	} else {
+		err = yaml.Unmarshal(trimmed, p)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("log: parse policy: %w", err)
+	}
+	if err := p.compile(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// LoadPolicyFile reads and parses a policy file.
+func LoadPolicyFile(path string) (*RedactionPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("log: load policy: %w", err)
+	}
+	return ParsePolicy(data)
+}
+
+// Policy implements PolicySource.
+func (p *RedactionPolicy) Policy() *RedactionPolicy {
+	return p
+}
+
+func (p *RedactionPolicy) compile() error {
+	p.compiled = make([]compiledRule, 0, len(p.Rules))
+	for i, r := range p.Rules {
+		r.Path = strings.TrimSpace(r.Path)
+		if r.Path == "" {
+			return fmt.Errorf("log: policy rule %d: empty path", i)
+		}
+		if r.Mask == "" && r.Ser == "" && r.Class == "" && !r.Omit {
+			return fmt.Errorf("log: policy rule %d (%s): no action", i, r.Path)
+		}
+		r.Class = parseClass(r.Class)
+		p.compiled = append(p.compiled, compiledRule{rule: r, segs: splitPattern(r.Path)})
+	}
+	return nil
+}
+
+// match returns the first rule matching a field, either by its path from the
+// root or by the qualified name of its enclosing struct type (owner may be nil).
+func (p *RedactionPolicy) match(path []string, owner reflect.Type, goName string) (PolicyRule, bool) {
+	if p == nil || len(path) == 0 {
+		return PolicyRule{}, false
+	}
+	name := path[len(path)-1]
+	for _, cr := range p.compiled {
+		if owner != nil && matchQualified(cr.rule.Path, owner, goName, name) {
+			return cr.rule, true
+		}
+		if matchSegments(cr.segs, path) {
+			return cr.rule, true
+		}
+	}
+	return PolicyRule{}, false
+}
+
+// apply merges a matching rule into the field options. It reports false if the field is omitted.
+func (r PolicyRule) apply(opts fieldOptions) (fieldOptions, bool) {
+	if r.Omit {
+		return opts, false
+	}
+	if r.Mask != "" {
+		opts.Mask = r.Mask
+	}
+	if r.Ser != "" {
+		opts.Serializer = r.Ser
+	}
+	if r.Class != "" {
+		opts.Class = r.Class
+	}
+	return opts, true
+}
+
+func matchQualified(pattern string, owner reflect.Type, goName, name string) bool {
+	i := strings.LastIndexByte(pattern, '.')
+	if i <= 0 {
+		return false
+	}
+	typeName, field := pattern[:i], pattern[i+1:]
+	if !strings.EqualFold(field, goName) && !strings.EqualFold(field, name) {
+		return false
+	}
+	if owner.Name() == "" {
+		return false
+	}
+	return typeName == owner.PkgPath()+"."+owner.Name() || typeName == owner.String()
+}
+
+// splitPattern splits "orders[].card" into ["orders", "[]", "card"].
+func splitPattern(pattern string) []string {
+	var segs []string
+	for _, part := range strings.Split(pattern, ".") {
+		for part != "" {
+			i := strings.IndexByte(part, '[')
+			if i < 0 {
+				segs = append(segs, part)
+				break
+			}
+			if i > 0 {
+				segs = append(segs, part[:i])
+			}
+			j := strings.IndexByte(part[i:], ']')
+			if j < 0 {
+				segs = append(segs, part[i:])
+				break
+			}
+			segs = append(segs, part[i:i+j+1])
+			part = part[i+j+1:]
+		}
+	}
+	return segs
+}
+
+// matchSegments matches path segments against pattern segments ("*" spans any number of segments).
+func matchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "*" {
+		for i := 0; i <= len(path); i++ {
+			if matchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 || !matchSegment(pattern[0], path[0]) {
+		return false
+	}
+	return matchSegments(pattern[1:], path[1:])
+}
+
+func matchSegment(pattern, seg string) bool {
+	if pattern == "[]" {
+		return strings.HasPrefix(seg, "[")
+	}
+	return strings.EqualFold(pattern, seg)
+}
+
+// ----- Hot Reload -----
+
+// PolicyWatcher reloads a policy file when its modification time changes.
+// A failed reload keeps the previous policy in effect; see Err.
+type PolicyWatcher struct {
+	path    string
+	current atomic.Pointer[RedactionPolicy]
+	modTime time.Time
+
+	mu      sync.Mutex
+	lastErr error
+	stop    chan struct{}
+	once    sync.Once
+}
+
+// WatchPolicyFile loads a policy file and polls it for changes every interval.
+func WatchPolicyFile(path string, interval time.Duration) (*PolicyWatcher, error) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	w := &PolicyWatcher{path: path, stop: make(chan struct{})}
+	if err := w.Reload(); err != nil {
+		return nil, err
+	}
+	go w.loop(interval)
+	return w, nil
+}
+
+// Policy implements PolicySource.
+func (w *PolicyWatcher) Policy() *RedactionPolicy {
+	return w.current.Load()
+}
+
+// Reload re-reads the policy file unconditionally.
+func (w *PolicyWatcher) Reload() error {
+	info, err := os.Stat(w.path)
+	if err == nil {
+		var p *RedactionPolicy
+		if p, err = LoadPolicyFile(w.path); err == nil {
+			w.current.Store(p)
+			w.mu.Lock()
+			w.modTime = info.ModTime()
+			w.mu.Unlock()
+		}
+	}
+	w.mu.Lock()
+	w.lastErr = err
+	w.mu.Unlock()
+	return err
+}
+
+// Err returns the error of the last reload attempt, if any.
+func (w *PolicyWatcher) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.lastErr
+}
+
+// Close stops watching the file.
+func (w *PolicyWatcher) Close() {
+	w.once.Do(func() { close(w.stop) })
+}
+
+func (w *PolicyWatcher) loop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			info, err := os.Stat(w.path)
+			if err != nil {
+				w.mu.Lock()
+				w.lastErr = err
+				w.mu.Unlock()
+				continue
+			}
+			w.mu.Lock()
+			changed := !info.ModTime().Equal(w.modTime)
+			w.mu.Unlock()
+			if changed {
+				_ = w.Reload()
+			}
+		}
+	}
+}
+
+// loadPolicy reads the active redaction policy into e.state, so that one call
+// sees a single policy even if the source is reloaded meanwhile.
+func (e *encoder) loadPolicy() error {
+	if e.opts.Policy == nil {
+		return nil
+	}
+	p, err := safePolicy(e.opts.Policy)
+	if err != nil {
+		return err
+	}
+	e.state.policy = p
+	return nil
+}
+
+// policy returns the active redaction policy, or nil.
+func (e *encoder) policy() *RedactionPolicy {
+	if e.state == nil {
+		return nil
+	}
+	return e.state.policy
+}
@@ -0,0 +1,261 @@
+package slog
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ----- Cycle and Shared Reference Handling -----
+
+// RefMode controls how cyclic and shared references are encoded.
+type RefMode int
+
+const (
+	// RefNone reports cycles as a MarshalError (default).
+	RefNone RefMode = iota
+	// RefCycles replaces a back-reference with {"$ref":"#/path/to/ancestor"}.
+	RefCycles
+	// RefShared also emits each pointer once and references it afterwards,
+	// which keeps graphs such as parent/child entities compact.
+	RefShared
+)
+
+const refKey = "$ref"
+
+// visitKey identifies a pointer, map or slice. The type is part of the key so
+// that a struct and its first field, which share an address, stay distinct.
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// cycleCheckDepth is the nesting depth from which RefNone also tracks maps and
+// slices. Cycles through them are rare, so shallower containers are not tracked
+// and a cycle is reported once it gets this deep.
+const cycleCheckDepth = 1000
+
+// refTarget is one encoded occurrence of a pointer, map or slice. References to
+// it are left as markers during encoding and resolved by resolveRefs.
+type refTarget struct {
+	node   any  // encoded output, set once done
+	done   bool // encoding finished
+	placed bool // found in the output by resolveRefs
+	path   string
+}
+
+// refMarker stands in for a reference until resolveRefs knows where its target ended up.
+type refMarker struct {
+	target *refTarget
+}
+
+// enterRef is called before encoding a pointer, map or slice. It reports whether
+// the value was handled as a reference (e.out is set) or failed as a cycle.
+// Otherwise it returns the target to record the encoded value in, if references
+// are enabled.
+func (e *encoder) enterRef(key visitKey, pointer bool) (bool, *refTarget, error) {
+	mode := e.opts.RefMode
+	if e.visited[key] {
+		if mode == RefNone {
+			return true, nil, newMarshalError(key.typ, "", e.path(), fmt.Errorf("cyclic reference detected"))
+		}
+		e.out = e.refTo(e.state.refs[key])
+		return true, nil, nil
+	}
+	if mode == RefNone {
+		return false, nil, nil
+	}
+
+	if e.state.refs == nil {
+		e.state.refs = make(map[visitKey]*refTarget)
+	}
+	if mode == RefShared && pointer {
+		if t, ok := e.state.refs[key]; ok {
+			e.out = e.refTo(t)
+			return true, nil, nil
+		}
+	}
+	// Cycles always point at the ancestor currently being encoded
+	t := &refTarget{}
+	if _, ok := e.state.refs[key]; !ok || mode == RefCycles || !pointer {
+		e.state.refs[key] = t
+	}
+	e.state.targets = append(e.state.targets, t)
+	return false, t, nil
+}
+
+// recordRef stores the encoded value of t once e is done.
+func (e *encoder) recordRef(t *refTarget) {
+	t.node, t.done = e.out, true
+}
+
+// refTo returns the output for a reference to t. Values that are not objects or
+// arrays are repeated instead of referenced.
+func (e *encoder) refTo(t *refTarget) any {
+	if t.done {
+		if _, ok := nodeID(t.node); !ok {
+			return t.node
+		}
+	}
+	e.state.hasRefs = true
+	return refMarker{target: t}
+}
+
+// nodeID identifies an object or non-empty array of the output tree.
+func nodeID(v any) (uintptr, bool) {
+	switch val := v.(type) {
+	case map[string]any:
+		return reflect.ValueOf(val).Pointer(), val != nil
+	case []any:
+		return reflect.ValueOf(val).Pointer(), len(val) > 0
+	}
+	return 0, false
+}
+
+// resolveRefs replaces reference markers with $ref pointers once the output
+// tree is complete, visiting object keys in output order. The first occurrence
+// that is actually written becomes the target: a marker whose target was
+// dropped (by a class policy, ShouldLog, an error fallback or a record field)
+// takes its place instead.
+func (e *encoder) resolveRefs() {
+	if e.state == nil || !e.state.hasRefs {
+		return
+	}
+	nodes := make(map[uintptr]*refTarget, len(e.state.targets))
+	for _, t := range e.state.targets {
+		if id, ok := nodeID(t.node); ok {
+			nodes[id] = t
+		}
+	}
+	e.out = resolveRef(e.out, nodes, nil)
+}
+
+func resolveRef(v any, nodes map[uintptr]*refTarget, segs []string) any {
+	if m, ok := v.(refMarker); ok {
+		if m.target.placed {
+			return map[string]any{refKey: m.target.path}
+		}
+		v = m.target.node
+	}
+	id, ok := nodeID(v)
+	if !ok {
+		return v
+	}
+	if t := nodes[id]; t != nil {
+		if t.placed {
+			return map[string]any{refKey: t.path}
+		}
+		t.placed, t.path = true, jsonPointer(segs)
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			sub := resolveRef(val[k], nodes, append(segs, k))
+			if _, marker := val[k].(refMarker); marker && sub == nil {
+				delete(val, k)
+				continue
+			}
+			val[k] = sub
+		}
+	case []any:
+		out := val[:0]
+		for _, sub := range val {
+			if sub = resolveRef(sub, nodes, append(segs, "["+strconv.Itoa(len(out))+"]")); sub != nil {
+				out = append(out, sub)
+			}
+		}
+		return out
+	}
+	return v
+}
+
+// refPointer returns the pointer of a {"$ref":...} object.
+func refPointer(m map[string]any) (string, bool) {
+	if len(m) != 1 {
+		return "", false
+	}
+	ref, ok := m[refKey].(string)
+	return ref, ok
+}
+
+// dropDanglingRefs removes $ref objects whose target was pruned by MaxBytes.
+// Array items are replaced with null so that later indices stay valid.
+func (e *encoder) dropDanglingRefs(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		if ref, ok := refPointer(val); ok {
+			if !hasPointer(e.out, ref) {
+				e.state.trunc.bytes++
+				return nil
+			}
+			return val
+		}
+		for k, sub := range val {
+			if sub = e.dropDanglingRefs(sub); sub != nil {
+				val[k] = sub
+			} else {
+				delete(val, k)
+			}
+		}
+	case []any:
+		for i, sub := range val {
+			val[i] = e.dropDanglingRefs(sub)
+		}
+	}
+	return v
+}
+
+// hasPointer reports whether the JSON Pointer fragment ref resolves in root.
+func hasPointer(root any, ref string) bool {
+	if !strings.HasPrefix(ref, "#") {
+		return false
+	}
+	cur := root
+	if ref == "#" {
+		return cur != nil
+	}
+	for _, seg := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		seg = strings.ReplaceAll(strings.ReplaceAll(seg, "~1", "/"), "~0", "~")
+		switch val := cur.(type) {
+		case map[string]any:
+			sub, ok := val[seg]
+			if !ok {
+				return false
+			}
+			cur = sub
+		case []any:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(val) {
+				return false
+			}
+			cur = val[i]
+		default:
+			return false
+		}
+	}
+	return cur != nil
+}
+
+// jsonPointer renders path segments as a URI fragment JSON Pointer (RFC 6901), e.g. "#/items/3/price".
+func jsonPointer(segs []string) string {
+	var b strings.Builder
+	b.WriteByte('#')
+	for _, seg := range segs {
+		b.WriteByte('/')
+		if strings.HasPrefix(seg, "[") && strings.HasSuffix(seg, "]") {
+			b.WriteString(seg[1 : len(seg)-1])
+			continue
+		}
+		seg = strings.ReplaceAll(seg, "~", "~0")
+		b.WriteString(strings.ReplaceAll(seg, "/", "~1"))
+	}
+	return b.String()
+}
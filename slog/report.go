@@ -0,0 +1,28 @@
+package slog
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ----- Error Fallback Reporting -----
+
+// errorKey holds the structured fallback written in place of a failed field.
+const errorKey = "_error"
+
+// FieldError describes a field that failed to serialize. With EnableErrorFallback
+// the field is replaced by {"_error":{...}} and the failure is returned by MarshalReport.
+type FieldError struct {
+	Path  string       // dotted path of the field, e.g. "items[3].price"
+	Field string       // Go field name
+	Type  reflect.Type // type of the field value
+	Err   error
+}
+
+func (f FieldError) Error() string {
+	return fmt.Sprintf("log: field %s: %v", f.Path, f.Err)
+}
+
+func (f FieldError) Unwrap() error {
+	return f.Err
+}
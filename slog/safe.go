@@ -0,0 +1,119 @@
+package slog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ----- Panic-Safe Calls Into User Code -----
+
+// PanicError is reported when user code called during encoding panics, e.g. a
+// MarshalLog, ShouldLog or IsZero method or a registered SerializerFunc.
+type PanicError struct {
+	Method string // the user hook that panicked, e.g. "MarshalLog"
+	Value  any    // the recovered panic value
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic in %s: %v", p.Method, p.Value)
+}
+
+// panicMask replaces values whose mask function panicked; nothing of the input is kept.
+const panicMask = "****"
+
+// recoverPanic turns a panic into a *PanicError stored in err. It must be deferred directly.
+func recoverPanic(method string, err *error) {
+	if r := recover(); r != nil {
+		*err = &PanicError{Method: method, Value: r}
+	}
+}
+
+func safeMarshalLog(lg SLogger) (b []byte, err error) {
+	defer recoverPanic("MarshalLog", &err)
+	return lg.MarshalLog()
+}
+
+func safeShouldLog(cl SConditionalLogger) (ok bool, err error) {
+	defer recoverPanic("ShouldLog", &err)
+	return cl.ShouldLog(), nil
+}
+
+func safeMarshalJSON(mj json.Marshaler) (b []byte, err error) {
+	defer recoverPanic("MarshalJSON", &err)
+	return mj.MarshalJSON()
+}
+
+func safeSerialize(name string, fn SerializerFunc, v any) (b []byte, err error) {
+	defer recoverPanic("serializer "+name, &err)
+	return fn(v)
+}
+
+func safePolicy(src PolicySource) (p *RedactionPolicy, err error) {
+	defer recoverPanic("Policy", &err)
+	return src.Policy(), nil
+}
+
+// safeExtract runs a context extractor; a panic is reported as an _error field.
+func safeExtract(fn ContextExtractor, ctx context.Context) (fields []Field) {
+	defer func() {
+		if r := recover(); r != nil {
+			fields = []Field{{Key: errorKey, Value: (&PanicError{Method: "ContextExtractor", Value: r}).Error()}}
+		}
+	}()
+	return fn(ctx)
+}
+
+// safeErrorString returns err.Error(), or a placeholder if Error panics.
+func safeErrorString(err error) (s string) {
+	defer func() {
+		if r := recover(); r != nil {
+			s = fmt.Sprintf("<panic:%v>", r)
+		}
+	}()
+	return err.Error()
+}
+
+// safeIsEmpty is isEmpty for values that may implement IsZeroer.
+func safeIsEmpty(v reflect.Value) (empty bool, err error) {
+	defer recoverPanic("IsZero", &err)
+	return isEmpty(v), nil
+}
+
+// safeMask wraps a mask so that a panic fully redacts the value instead of escaping.
+func safeMask(fn MaskFunc) MaskFunc {
+	return func(s string) (out string) {
+		defer func() {
+			if r := recover(); r != nil {
+				out = panicMask
+			}
+		}()
+		return fn(s)
+	}
+}
+
+// omitEmpty reports whether a field with omitempty semantics is skipped. A panic
+// in IsZero is handled like any other field error.
+func (e *encoder) omitEmpty(sf reflect.StructField, fv reflect.Value, fi fieldInfo, m map[string]any) (bool, error) {
+	empty, err := safeIsEmpty(fv)
+	if err == nil {
+		return empty, nil
+	}
+	if e.opts.EnableErrorFallback {
+		m[fi.opts.Name] = e.errorFallback(sf, fv, fi, err)
+		return true, nil
+	}
+	return true, err
+}
+
+// rootError wraps a panic in the top-level MarshalLog call into a MarshalError;
+// errors returned by MarshalLog are passed through unchanged.
+func rootError(v any, err error) error {
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		return &MarshalError{Type: reflect.TypeOf(v), Err: err}
+	}
+	return err
+}
@@ -0,0 +1,293 @@
+package slog
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ----- JSON Schema Generation -----
+
+// SchemaDraft is the JSON Schema dialect produced by Schema.
+const SchemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// errorDef is the $defs entry describing encoded errors.
+const errorDef = "error"
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	conditionalLogger = reflect.TypeOf((*SConditionalLogger)(nil)).Elem()
+
+	serSchemas sync.Map // serializer name → output schema
+)
+
+// RegisterSerializerSchema declares the JSON Schema of a serializer's output.
+// For parameterized serializers, the base name ("bucket") covers every call.
+func RegisterSerializerSchema(name string, schema map[string]any) {
+	if name == "" {
+		return
+	}
+	serSchemas.Store(name, schema)
+}
+
+// RegisterSerializerWithSchema registers a serializer together with its output schema.
+func RegisterSerializerWithSchema(name string, fn SerializerFunc, schema map[string]any) {
+	RegisterSerializer(name, fn)
+	RegisterSerializerSchema(name, schema)
+}
+
+// serializerSchema returns the declared output schema of a serializer, or {} if unknown.
+func serializerSchema(name string) map[string]any {
+	if v, ok := serSchemas.Load(name); ok {
+		return copySchema(v.(map[string]any))
+	}
+	if base, _, ok := parseSerializerCall(name); ok {
+		if v, ok := serSchemas.Load(base); ok {
+			return copySchema(v.(map[string]any))
+		}
+	}
+	return map[string]any{}
+}
+
+// copySchema returns a shallow copy, so callers can add keywords.
+func copySchema(s map[string]any) map[string]any {
+	out := make(map[string]any, len(s))
+	for k, v := range s {
+		out[k] = v
+	}
+	return out
+}
+
+func typeSchema(typ string) map[string]any {
+	return map[string]any{"type": typ}
+}
+
+// Schema returns a JSON Schema (draft 2020-12) describing the output of Marshal
+// for values of type t under opts. It reflects tag names, omitempty (fields are
+// not required), inline flattening, masks and the string option (strings), and
+// serializer output as declared with RegisterSerializerSchema. Values from
+// MarshalLog and unknown serializers are unconstrained. Recursive types are
+// described with $defs.
+func Schema(t reflect.Type, opts ...Option) map[string]any {
+	enc := newEncoder()
+	defer releaseEncoder(enc)
+	enc.opts = newOptions(opts)
+
+	g := &schemaGen{
+		e:         enc,
+		root:      derefType(t),
+		defs:      make(map[string]any),
+		visiting:  make(map[reflect.Type]bool),
+		recursive: make(map[reflect.Type]bool),
+	}
+	s := g.schema(t)
+	s["$schema"] = SchemaDraft
+	if name := g.root.Name(); name != "" {
+		s["title"] = name
+	}
+	if len(g.defs) > 0 {
+		s["$defs"] = g.defs
+	}
+	return s
+}
+
+type schemaGen struct {
+	e         *encoder
+	root      reflect.Type
+	defs      map[string]any
+	visiting  map[reflect.Type]bool
+	recursive map[reflect.Type]bool
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// schema mirrors encodeReflect for a static type.
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	t = derefType(t)
+	if t.Implements(errorType) || reflect.PointerTo(t).Implements(errorType) {
+		if g.e.opts.DisableLoggerInterface || !t.Implements(loggerType) {
+			return g.errorSchema()
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.structSchema(t)
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	case reflect.String:
+		return typeSchema("string")
+	case reflect.Bool:
+		return typeSchema("boolean")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return typeSchema("integer")
+	case reflect.Float32, reflect.Float64:
+		return typeSchema("number")
+	}
+	return map[string]any{}
+}
+
+// errorSchema describes the structured error objects of encodeError, defined once in $defs.
+func (g *schemaGen) errorSchema() map[string]any {
+	ref := map[string]any{"$ref": "#/$defs/" + errorDef}
+	if _, ok := g.defs[errorDef]; ok {
+		return ref
+	}
+	link := func() map[string]any {
+		return map[string]any{
+			"message": typeSchema("string"),
+			"type":    typeSchema("string"),
+			"code":    typeSchema("string"),
+		}
+	}
+	props := link()
+	props["chain"] = map[string]any{"type": "array", "items": map[string]any{
+		"type":       "object",
+		"properties": link(),
+		"required":   []string{"message", "type"},
+	}}
+	props["errors"] = map[string]any{"type": "array", "items": ref}
+	props["stack"] = map[string]any{"type": "array", "items": typeSchema("string")}
+	g.defs[errorDef] = map[string]any{
+		"type":       "object",
+		"properties": props,
+		"required":   []string{"message", "type"},
+	}
+	return ref
+}
+
+func (g *schemaGen) structSchema(t reflect.Type) map[string]any {
+	pt := reflect.PointerTo(t)
+	if !g.e.opts.DisableLoggerInterface && (t.Implements(loggerType) || pt.Implements(loggerType)) {
+		return map[string]any{}
+	}
+
+	if g.visiting[t] {
+		g.recursive[t] = true
+		return g.ref(t)
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	info := g.e.getStructInfo(t)
+	if !info.hasLogTag && !g.e.opts.DisableJSONFallback {
+		if t == timeType {
+			return map[string]any{"type": "string", "format": "date-time"}
+		}
+		if t.Implements(marshalerType) || pt.Implements(marshalerType) {
+			return map[string]any{}
+		}
+	}
+
+	props := make(map[string]any)
+	var required []string
+	g.addFields(t, info, props, &required)
+
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	if g.recursive[t] && t != g.root {
+		g.defs[defName(t)] = s
+		return g.ref(t)
+	}
+	return s
+}
+
+func (g *schemaGen) ref(t reflect.Type) map[string]any {
+	if t == g.root {
+		return map[string]any{"$ref": "#"}
+	}
+	return map[string]any{"$ref": "#/$defs/" + defName(t)}
+}
+
+// defName names a type in $defs, e.g. "slog.Node" becomes "slog_Node".
+func defName(t reflect.Type) string {
+	return strings.NewReplacer(".", "_", "/", "_", "[", "_", "]", "_", " ", "_", "*", "").Replace(t.String())
+}
+
+// addFields adds the properties of struct t, flattening inline fields into props.
+func (g *schemaGen) addFields(t reflect.Type, info *structInfo, props map[string]any, required *[]string) {
+	e := g.e
+	if !info.hasLogTag {
+		if e.opts.DisableJSONFallback {
+			return
+		}
+		for _, fi := range info.fields {
+			if fi.jsonName == "" || fi.jsonName == "-" {
+				continue
+			}
+			ft := t.Field(fi.index).Type
+			props[fi.jsonName] = g.schema(ft)
+			if !fi.jsonOpts.Contains("omitempty") && !nullable(ft) {
+				*required = append(*required, fi.jsonName)
+			}
+		}
+		return
+	}
+
+	for _, fi := range info.fields {
+		if fi.opts.Name == "-" || (fi.opts.HasLevel && e.opts.Level > fi.opts.Level) {
+			continue
+		}
+		action := e.classAction(fi.opts.Class)
+		if action == ClassDrop {
+			continue
+		}
+		ft := t.Field(fi.index).Type
+
+		if fi.opts.Inline && derefType(ft).Kind() == reflect.Struct && !e.classMasked(fi) {
+			inner := derefType(ft)
+			g.addFields(inner, e.getStructInfo(inner), props, required)
+			continue
+		}
+		if fi.opts.Name == "" {
+			continue
+		}
+
+		props[fi.opts.Name] = g.fieldSchema(ft, fi, action)
+		optional := fi.opts.OmitEmpty || e.opts.OmitEmptyByDefault || nullable(ft) ||
+			ft.Implements(conditionalLogger)
+		if !optional {
+			*required = append(*required, fi.opts.Name)
+		}
+	}
+}
+
+// fieldSchema mirrors encodeField: serializers, then MarshalLog, then the value
+// with masks, precision and string coercion applied.
+func (g *schemaGen) fieldSchema(ft reflect.Type, fi fieldInfo, action string) map[string]any {
+	e := g.e
+	switch {
+	case action == ClassKeep:
+	case action != "":
+		return typeSchema("string")
+	case fi.opts.Serializer != "":
+		return serializerSchema(fi.opts.Serializer)
+	case fi.opts.Mask != "" && isScalarKind(derefType(ft).Kind()):
+		return typeSchema("string")
+	}
+
+	if !e.opts.DisableLoggerInterface && ft.Implements(loggerType) {
+		return map[string]any{}
+	}
+	s := g.schema(ft)
+	if fi.opts.String {
+		return typeSchema("string")
+	}
+	return s
+}
+
+// nullable reports whether values of t can be encoded as absent.
+func nullable(t reflect.Type) bool {
+	return t.Kind() == reflect.Ptr || t.Kind() == reflect.Interface
+}
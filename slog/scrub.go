@@ -0,0 +1,85 @@
+package slog
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+)
+
+// ----- Scrub Rules -----
+
+// ScrubRule replaces every match of Pattern in emitted strings with Replacement.
+// Replacement is a regexp template, so "$1" and "${name}" refer to capture groups.
+type ScrubRule struct {
+	Pattern     string
+	Replacement string
+}
+
+// Common scrub rules.
+var (
+	ScrubCardRule  = ScrubRule{Pattern: `\b\d{16}\b`, Replacement: "[CARD]"}
+	ScrubEmailRule = ScrubRule{Pattern: `[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`, Replacement: "[EMAIL]"}
+)
+
+// Scrubber applies precompiled scrub rules, in order, to every string value
+// of a record, including plain messages and map values.
+type Scrubber struct {
+	mu    sync.RWMutex
+	rules []scrubRule
+}
+
+type scrubRule struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+// NewScrubber compiles rules into a scrubber.
+func NewScrubber(rules ...ScrubRule) (*Scrubber, error) {
+	s := &Scrubber{}
+	if err := s.Add(rules...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Add compiles and appends rules. No rule is added if any fails to compile.
+func (s *Scrubber) Add(rules ...ScrubRule) error {
+	compiled := make([]scrubRule, 0, len(rules))
+	for _, r := range rules {
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("log: scrub rule %q: %w", r.Pattern, err)
+		}
+		compiled = append(compiled, scrubRule{re: re, repl: r.Replacement})
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(s.rules, compiled...)
+	return nil
+}
+
+// Scrub applies all rules to str.
+func (s *Scrubber) Scrub(str string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, r := range s.rules {
+		str = r.re.ReplaceAllString(str, r.repl)
+	}
+	return str
+}
+
+// Len returns the number of rules.
+func (s *Scrubber) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.rules)
+}
+
+// ----- Global Scrubber -----
+
+var globalScrubber = &Scrubber{}
+
+// RegisterScrubRule adds rules applied to every record, in addition to Options.Scrubber.
+func RegisterScrubRule(rules ...ScrubRule) error {
+	return globalScrubber.Add(rules...)
+}
@@ -0,0 +1,214 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ----- Secret Detection -----
+
+// SecretScanner finds credentials inside free-form strings (error messages,
+// details, map values) and replaces them with a redaction marker.
+// It recognizes known token formats and, optionally, high-entropy substrings.
+type SecretScanner struct {
+	MinLength  int     // Minimum length of a high-entropy candidate (0 disables entropy detection)
+	MinEntropy float64 // Minimum Shannon entropy in bits per character
+	Marker     string  // Replacement format; %s receives the detector name
+
+	mu       sync.RWMutex
+	patterns []secretPattern
+}
+
+type secretPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+var (
+	builtinSecretPatterns = []secretPattern{
+		{"private_key", regexp.MustCompile(`-----BEGIN [A-Z0-9 ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z0-9 ]*PRIVATE KEY-----`)},
+		{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`)},
+		{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`)},
+		{"github_token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+		{"slack_token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	}
+
+	// secretCandidate splits strings into base64/hex-like words for entropy checks
+	secretCandidate = regexp.MustCompile(`[A-Za-z0-9+/=_\-]+`)
+)
+
+// NewSecretScanner creates a scanner with the built-in token formats and
+// entropy detection for candidates of at least 20 characters and 4.0 bits/char.
+func NewSecretScanner() *SecretScanner {
+	return &SecretScanner{
+		MinLength:  20,
+		MinEntropy: 4.0,
+		Marker:     "[REDACTED:%s]",
+		patterns:   append([]secretPattern(nil), builtinSecretPatterns...),
+	}
+}
+
+// AddPattern adds a named token format.
+func (s *SecretScanner) AddPattern(name, expr string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("log: secret pattern %q: %w", name, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.patterns = append(s.patterns, secretPattern{name: name, re: re})
+	return nil
+}
+
+// Redact returns str with every detected secret replaced by the marker.
+func (s *SecretScanner) Redact(str string) string {
+	if str == "" || isSealed(str) {
+		return str
+	}
+	s.mu.RLock()
+	for _, p := range s.patterns {
+		if p.re.MatchString(str) {
+			str = p.re.ReplaceAllLiteralString(str, s.marker(p.name))
+		}
+	}
+	s.mu.RUnlock()
+
+	if s.MinLength > 0 && len(str) >= s.MinLength {
+		str = secretCandidate.ReplaceAllStringFunc(str, func(w string) string {
+			if len(w) >= s.MinLength && looksRandom(w) && shannonEntropy(w) >= s.MinEntropy {
+				return s.marker("high_entropy")
+			}
+			return w
+		})
+	}
+	return str
+}
+
+// Contains reports whether str contains a detectable secret.
+func (s *SecretScanner) Contains(str string) bool {
+	return s.Redact(str) != str
+}
+
+func (s *SecretScanner) marker(name string) string {
+	if s.Marker == "" {
+		return "[REDACTED]"
+	}
+	if strings.Contains(s.Marker, "%s") {
+		return fmt.Sprintf(s.Marker, name)
+	}
+	return s.Marker
+}
+
+// shannonEntropy returns the entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	var counts [256]int
+	for i := 0; i < len(s); i++ {
+		counts[s[i]]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// looksRandom requires both letters and digits, which rules out long words and numbers.
+func looksRandom(s string) bool {
+	var letter, digit bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			letter = true
+		}
+	}
+	return letter && digit
+}
+
+// ----- Output String Transformation -----
+
+// transformStrings applies fn to every string value of an encoded output tree.
+// Map keys are left untouched; raw JSON from SLogger and serializers is decoded,
+// transformed and re-encoded.
+func transformStrings(v any, fn func(string) string) any {
+	switch val := v.(type) {
+	case string:
+		return fn(val)
+	case map[string]any:
+		for k, sub := range val {
+			val[k] = transformStrings(sub, fn)
+		}
+	case []any:
+		for i, sub := range val {
+			val[i] = transformStrings(sub, fn)
+		}
+	case json.RawMessage:
+		return transformRaw(val, func(decoded any) any {
+			return transformStrings(decoded, fn)
+		})
+	}
+	return v
+}
+
+// transformRaw decodes raw JSON (keeping numbers exact), applies fn and
+// re-encodes the result. raw is returned unchanged if it cannot be decoded.
+func transformRaw(raw json.RawMessage, fn func(any) any) json.RawMessage {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var decoded any
+	if err := dec.Decode(&decoded); err != nil {
+		return raw
+	}
+	b, err := json.Marshal(fn(decoded))
+	if err != nil {
+		return raw
+	}
+	return json.RawMessage(b)
+}
+
+// redactOutput runs the configured string transformations over the encoded output.
+func (e *encoder) redactOutput() {
+	if e.out == nil {
+		return
+	}
+	if fn := redactor(e.opts); fn != nil {
+		e.out = transformStrings(e.out, fn)
+	}
+}
+
+// redactor returns the string transformations configured in o: global scrub
+// rules, then Options.Scrubber, then the secret scanner. It returns nil if
+// there are none.
+func redactor(o *Options) func(string) string {
+	var fns []func(string) string
+	if globalScrubber.Len() > 0 {
+		fns = append(fns, globalScrubber.Scrub)
+	}
+	if o.Scrubber != nil {
+		fns = append(fns, o.Scrubber.Scrub)
+	}
+	if o.SecretScanner != nil {
+		fns = append(fns, o.SecretScanner.Redact)
+	}
+	if len(fns) == 0 {
+		return nil
+	}
+	return func(s string) string {
+		for _, fn := range fns {
+			s = fn(s)
+		}
+		return s
+	}
+}
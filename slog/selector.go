@@ -0,0 +1,90 @@
+package slog
+
+import "strconv"
+
+// ----- Include/Exclude Path Selection -----
+
+// pathSelector decides which paths are walked during encoding. Paths use the
+// same syntax as policy rules: dotted names, "[]" for any collection index and
+// "*" for any number of segments, e.g. "items[].sku" or "*.raw".
+type pathSelector struct {
+	include [][]string
+	exclude [][]string
+}
+
+// newPathSelector compiles include/exclude paths; it returns nil if both are empty.
+func newPathSelector(include, exclude []string) *pathSelector {
+	if len(include) == 0 && len(exclude) == 0 {
+		return nil
+	}
+	s := &pathSelector{}
+	for _, p := range include {
+		if p != "" {
+			s.include = append(s.include, splitPattern(p))
+		}
+	}
+	for _, p := range exclude {
+		if p != "" {
+			s.exclude = append(s.exclude, splitPattern(p))
+		}
+	}
+	return s
+}
+
+// allows reports whether the value at path is serialized. Excluded paths drop
+// their whole subtree. With include paths, a path is kept if it is included,
+// lies below an included path, or lies on the way to one.
+func (s *pathSelector) allows(path []string) bool {
+	for _, p := range s.exclude {
+		if matchSegments(p, path) {
+			return false
+		}
+	}
+	if len(s.include) == 0 {
+		return true
+	}
+	for _, p := range s.include {
+		if matchPrefix(p, path) {
+			return true
+		}
+		for k := 1; k <= len(path); k++ {
+			if matchSegments(p, path[:k]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchPrefix reports whether path could be extended into a match of pattern.
+func matchPrefix(pattern, path []string) bool {
+	if len(path) == 0 {
+		return true
+	}
+	if len(pattern) == 0 {
+		return false
+	}
+	if pattern[0] == "*" {
+		return true
+	}
+	if !matchSegment(pattern[0], path[0]) {
+		return false
+	}
+	return matchPrefix(pattern[1:], path[1:])
+}
+
+// selects reports whether the child of e at segment seg is serialized.
+func (e *encoder) selects(seg string) bool {
+	if e.state == nil || e.state.sel == nil {
+		return true
+	}
+	return e.state.sel.allows(append(e.path(), seg))
+}
+
+// selectsIndex reports whether the element of e at collection index i is serialized.
+func (e *encoder) selectsIndex(i int) bool {
+	if e.state == nil || e.state.sel == nil {
+		return true
+	}
+	return e.state.sel.allows(append(e.path(), "["+strconv.Itoa(i)+"]"))
+}
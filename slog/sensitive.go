@@ -0,0 +1,225 @@
+package slog
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ----- Sensitive Data Heuristics -----
+
+// SensitiveDetector detects sensitive data by key name and by value pattern.
+// With Options.MaskSensitive it is applied to untagged and dynamic data: map
+// entries, json-tag fallback fields, collection elements and the JSON returned
+// by MarshalJSON or MarshalLog.
+//
+// Values under a sensitive key are masked entirely, nested objects and arrays
+// included; other strings have every substring matching a value pattern
+// replaced by its mask.
+type SensitiveDetector struct {
+	mu       sync.RWMutex
+	keys     []string
+	patterns []sensitivePattern
+}
+
+type sensitivePattern struct {
+	re   *regexp.Regexp
+	mask string
+}
+
+var (
+	defaultSensitiveKeys = []string{
+		"password", "passwd", "pwd", "secret", "token", "key",
+		"credential", "auth", "private", "credit", "ssn",
+		"phone", "email", "address", "card", "bank",
+	}
+	defaultSensitivePatterns = []sensitivePattern{
+		{regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), "email"},
+		{regexp.MustCompile(`\d{4}[-\s]?\d{4}[-\s]?\d{4}[-\s]?\d{4}`), defaultMK},
+		{regexp.MustCompile(`\d{11}`), "phone"},
+	}
+
+	defaultSensitive = NewSensitiveDetector()
+)
+
+// NewSensitiveDetector creates a detector with the built-in keys and patterns
+// (password/token/secret-like keys; email, card and phone values).
+func NewSensitiveDetector() *SensitiveDetector {
+	d := &SensitiveDetector{
+		keys:     append([]string(nil), defaultSensitiveKeys...),
+		patterns: append([]sensitivePattern(nil), defaultSensitivePatterns...),
+	}
+	return d
+}
+
+// AddKeys adds key substrings, matched case-insensitively against field and map key names.
+func (d *SensitiveDetector) AddKeys(keys ...string) *SensitiveDetector {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, k := range keys {
+		if k = normalizeKey(k); k != "" {
+			d.keys = append(d.keys, k)
+		}
+	}
+	return d
+}
+
+// AddPattern adds a value pattern whose matches are replaced using the named mask.
+func (d *SensitiveDetector) AddPattern(expr, mask string) error {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("log: sensitive pattern %q: %w", expr, err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.patterns = append(d.patterns, sensitivePattern{re: re, mask: mask})
+	return nil
+}
+
+// IsSensitiveKey reports whether a field or map key name looks sensitive.
+func (d *SensitiveDetector) IsSensitiveKey(name string) bool {
+	if name == "" {
+		return false
+	}
+	name = normalizeKey(name)
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, k := range d.keys {
+		if strings.Contains(name, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchValue reports whether a value contains data matching a sensitive pattern.
+func (d *SensitiveDetector) MatchValue(s string) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, p := range d.patterns {
+		if p.re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// MaskValue replaces every sensitive substring of s with its masked form,
+// using the globally registered masks.
+func (d *SensitiveDetector) MaskValue(s string) string {
+	return d.maskValue(s, func(name string) MaskFunc { return safeMask(getMask(name)) })
+}
+
+// maskValue is MaskValue with masks resolved by lookup, so that the encoder's
+// Hasher and KeyProvider apply.
+func (d *SensitiveDetector) maskValue(s string, lookup func(string) MaskFunc) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	for _, p := range d.patterns {
+		s = p.re.ReplaceAllStringFunc(s, lookup(p.mask))
+	}
+	return s
+}
+
+func normalizeKey(k string) string {
+	k = strings.ToLower(strings.TrimSpace(k))
+	return strings.NewReplacer("_", "", "-", "", ".", "").Replace(k)
+}
+
+// RegisterSensitiveKey adds key substrings to the process-wide detector.
+func RegisterSensitiveKey(keys ...string) {
+	defaultSensitive.AddKeys(keys...)
+}
+
+// RegisterSensitivePattern adds a value pattern to the process-wide detector.
+func RegisterSensitivePattern(expr, mask string) error {
+	return defaultSensitive.AddPattern(expr, mask)
+}
+
+// sensitive returns the detector in effect.
+func (e *encoder) sensitive() *SensitiveDetector {
+	if e.opts.SensitiveDetector != nil {
+		return e.opts.SensitiveDetector
+	}
+	return defaultSensitive
+}
+
+// maskDynamic applies the sensitive heuristics to a value found under key name
+// in untagged or dynamic data. It is a no-op unless MaskSensitive is enabled.
+func (e *encoder) maskDynamic(name string, val any) any {
+	if !e.opts.MaskSensitive || val == nil {
+		return val
+	}
+	d := e.sensitive()
+	if d.IsSensitiveKey(name) {
+		return maskSubtree(val)
+	}
+	if s, ok := val.(string); ok {
+		return d.maskValue(s, e.getMask)
+	}
+	return val
+}
+
+// maskSubtree masks every scalar in val, which was found under a sensitive key.
+// Nested objects and arrays are masked as a whole, whatever their own keys.
+func maskSubtree(val any) any {
+	switch v := val.(type) {
+	case string:
+		return defaultMask(v)
+	case json.Number:
+		return defaultMask(v.String())
+	case bool, float32, float64, int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return defaultMask(fmt.Sprintf("%v", v))
+	case map[string]any:
+		for k, sub := range v {
+			v[k] = maskSubtree(sub)
+		}
+	case []any:
+		for i, sub := range v {
+			v[i] = maskSubtree(sub)
+		}
+	case json.RawMessage:
+		return transformRaw(v, maskSubtree)
+	}
+	return val
+}
+
+// maskRaw applies the sensitive heuristics to raw JSON returned by MarshalJSON
+// or MarshalLog, which bypasses the encoder. name is the key the value is
+// logged under.
+func (e *encoder) maskRaw(name string, raw []byte) json.RawMessage {
+	if !e.opts.MaskSensitive {
+		return raw
+	}
+	return transformRaw(raw, func(decoded any) any {
+		return e.maskTree(name, decoded)
+	})
+}
+
+// maskTree applies maskDynamic bottom-up to decoded JSON.
+func (e *encoder) maskTree(name string, val any) any {
+	switch v := val.(type) {
+	case map[string]any:
+		for k, sub := range v {
+			v[k] = e.maskTree(k, sub)
+		}
+	case []any:
+		for i, sub := range v {
+			v[i] = e.maskTree(name, sub)
+		}
+	}
+	return e.maskDynamic(name, val)
+}
+
+// nearestName returns the closest named (non-index) path segment of e.
+func (e *encoder) nearestName() string {
+	for cur := e; cur != nil; cur = cur.parent {
+		if !cur.hasIdx && cur.seg != "" {
+			return cur.seg
+		}
+	}
+	return ""
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -36,7 +38,7 @@ func getLazySerializer(name string) (SerializerFunc, bool) {
 	lazySerializersMu.RUnlock()
 
 	if !exists {
-		return nil, false
+		return getParamSerializer(name)
 	}
 
 	// Create the serializer on first use
@@ -56,6 +58,61 @@ func getLazySerializer(name string) (SerializerFunc, bool) {
 	return serializerFunc, true
 }
 
+// ----- Parameterized Serializer Registry -----
+
+// SerializerFactory builds a serializer from the arguments of a parameterized
+// name such as "bucket(10)" or "round_time(1h)".
+type SerializerFactory func(args []string) (SerializerFunc, error)
+
+var serFactories sync.Map
+
+// RegisterSerializerFactory registers a factory for parameterized serializers.
+// Tags then refer to it as ser=name(arg1|arg2).
+func RegisterSerializerFactory(name string, factory SerializerFactory) {
+	if name == "" {
+		fmt.Printf("log: serializer factory name is nil, return\n")
+		return
+	}
+	serFactories.Store(name, factory)
+}
+
+// getParamSerializer resolves "name(args)" through a registered factory and caches the result.
+// Invalid arguments yield a serializer that reports the error, so the field's error handling applies.
+func getParamSerializer(full string) (SerializerFunc, bool) {
+	name, args, ok := parseSerializerCall(full)
+	if !ok {
+		return nil, false
+	}
+	v, ok := serFactories.Load(name)
+	if !ok {
+		return nil, false
+	}
+	fn, err := v.(SerializerFactory)(args)
+	if err != nil {
+		err = fmt.Errorf("serializer %q: %w", full, err)
+		return func(any) ([]byte, error) { return nil, err }, true
+	}
+	serReg.Store(full, fn)
+	return fn, true
+}
+
+// parseSerializerCall splits "bucket(10)" into ("bucket", ["10"]). Arguments are separated by '|'.
+func parseSerializerCall(s string) (string, []string, bool) {
+	open := strings.IndexByte(s, '(')
+	if open <= 0 || !strings.HasSuffix(s, ")") {
+		return "", nil, false
+	}
+	name, inner := s[:open], strings.TrimSpace(s[open+1:len(s)-1])
+	if inner == "" {
+		return name, nil, true
+	}
+	args := strings.Split(inner, "|")
+	for i := range args {
+		args[i] = strings.TrimSpace(args[i])
+	}
+	return name, args, true
+}
+
 // RegisterCurrencyFormattedSerializer registers currency serializers.
 func RegisterCurrencyFormattedSerializer() {
 	currencyFormats := map[string]struct {
@@ -74,6 +131,8 @@ func RegisterCurrencyFormattedSerializer() {
 
 	for name, format := range currencyFormats {
 		name := name
+		RegisterSerializerSchema(name, typeSchema("string"))
+		RegisterSerializerInverse(name, currencyInverse(format.symbol))
 		symbol := format.symbol
 		decimals := format.decimals
 
@@ -94,6 +153,8 @@ func RegisterCurrencyFormattedSerializer() {
 		})
 	}
 
+	RegisterSerializerSchema("currency", typeSchema("string"))
+	RegisterSerializerInverse("currency", currencyInverse("¥"))
 	RegisterLazySerializer("currency", func() SerializerFunc {
 		return func(v any) ([]byte, error) {
 			return currencySerializer(v, "¥", 2)
@@ -117,6 +178,8 @@ func currencySerializer(v any, symbol string, decimals int) ([]byte, error) {
 
 // RegisterDurationFormattedSerializer registers duration serializers.
 func RegisterDurationFormattedSerializer() {
+	RegisterSerializerSchema("duration", typeSchema("integer"))
+	RegisterSerializerInverse("duration", durationUnitInverse(time.Millisecond))
 	RegisterLazySerializer("duration", func() SerializerFunc {
 		return func(v any) ([]byte, error) {
 			if d, ok := v.(time.Duration); ok {
@@ -181,8 +244,30 @@ func RegisterDurationFormattedSerializer() {
 		},
 	}
 
+	durationSchemas := map[string]string{
+		"duration_ns": "integer", "duration_us": "integer", "duration_ms": "integer", "duration_sec_int": "integer",
+		"duration_sec": "number", "duration_min": "number", "duration_hr": "number",
+	}
+	durationInverses := map[string]InverseFunc{
+		"duration_ns":      durationUnitInverse(time.Nanosecond),
+		"duration_us":      durationUnitInverse(time.Microsecond),
+		"duration_ms":      durationUnitInverse(time.Millisecond),
+		"duration_sec":     durationUnitInverse(time.Second),
+		"duration_sec_int": durationUnitInverse(time.Second),
+		"duration_min":     durationUnitInverse(time.Minute),
+		"duration_hr":      durationUnitInverse(time.Hour),
+		"duration_string":  durationStringInverse,
+		"duration_short":   durationStringInverse,
+		"duration_human":   humanDurationInverse,
+	}
 	for name, formatter := range durationFormats {
 		formatter := formatter
+		RegisterSerializerInverse(name, durationInverses[name])
+		if typ, ok := durationSchemas[name]; ok {
+			RegisterSerializerSchema(name, typeSchema(typ))
+		} else {
+			RegisterSerializerSchema(name, typeSchema("string"))
+		}
 		RegisterLazySerializer(name, func() SerializerFunc {
 			return func(v any) ([]byte, error) {
 				if d, ok := v.(time.Duration); ok {
@@ -195,6 +280,8 @@ func RegisterDurationFormattedSerializer() {
 }
 
 func RegisterDurationSerializerWithPrecision(name string, unit time.Duration, precision int) {
+	RegisterSerializerSchema(name, typeSchema("number"))
+	RegisterSerializerInverse(name, durationUnitInverse(unit))
 	RegisterLazySerializer(name, func() SerializerFunc {
 		return func(v any) ([]byte, error) {
 			if d, ok := v.(time.Duration); ok {
@@ -214,6 +301,8 @@ func RegisterDurationSerializerWithPrecision(name string, unit time.Duration, pr
 
 // RegisterTimeFormattedSerializer registers time serializers.
 func RegisterTimeFormattedSerializer() {
+	RegisterSerializerSchema("time_rfc3339", map[string]any{"type": "string", "format": "date-time"})
+	RegisterSerializerInverse("time_rfc3339", timeLayoutInverse(time.RFC3339))
 	RegisterLazySerializer("time_rfc3339", func() SerializerFunc {
 		return func(v any) ([]byte, error) {
 			if t, ok := v.(time.Time); ok {
@@ -238,6 +327,20 @@ func RegisterTimeFormattedSerializer() {
 
 	for name, layout := range timeFormats {
 		layout := layout
+		if layout != "" {
+			RegisterSerializerInverse(name, timeLayoutInverse(layout))
+		}
+		switch name {
+		case "time_unix", "time_unix_ms", "time_unix_ns":
+			RegisterSerializerSchema(name, typeSchema("integer"))
+			RegisterSerializerInverse(name, unixTimeInverse(name))
+		case "time_iso8601":
+			RegisterSerializerSchema(name, map[string]any{"type": "string", "format": "date-time"})
+		case "time_date":
+			RegisterSerializerSchema(name, map[string]any{"type": "string", "format": "date"})
+		default:
+			RegisterSerializerSchema(name, typeSchema("string"))
+		}
 		RegisterLazySerializer(name, func() SerializerFunc {
 			return func(v any) ([]byte, error) {
 				if t, ok := v.(time.Time); ok {
@@ -259,6 +362,8 @@ func RegisterTimeFormattedSerializer() {
 }
 
 func RegisterTimeSerializerWithLayout(name, layout string) {
+	RegisterSerializerSchema(name, typeSchema("string"))
+	RegisterSerializerInverse(name, timeLayoutInverse(layout))
 	RegisterLazySerializer(name, func() SerializerFunc {
 		return func(v any) ([]byte, error) {
 			if t, ok := v.(time.Time); ok {
@@ -268,3 +373,100 @@ func RegisterTimeSerializerWithLayout(name, layout string) {
 		}
 	})
 }
+
+// ----- Serializer Inverses -----
+
+// currencyInverse parses "$12.50" back into a float64.
+func currencyInverse(symbol string) InverseFunc {
+	return func(data []byte, _ reflect.Type) (any, error) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return strconv.ParseFloat(strings.TrimPrefix(s, symbol), 64)
+	}
+}
+
+// durationUnitInverse parses a number of units back into a time.Duration.
+func durationUnitInverse(unit time.Duration) InverseFunc {
+	return func(data []byte, _ reflect.Type) (any, error) {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		if i, err := n.Int64(); err == nil {
+			return time.Duration(i) * unit, nil
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return time.Duration(math.Round(f * float64(unit))), nil
+	}
+}
+
+// durationStringInverse parses "1h2m3s" and the rounded "1.50ms" of duration_short.
+func durationStringInverse(data []byte, _ reflect.Type) (any, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return time.ParseDuration(s)
+}
+
+// humanDurationInverse parses the "1d 2h 3m 4s" and "250 ms" forms of duration_human.
+func humanDurationInverse(data []byte, _ reflect.Type) (any, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	if ms, ok := strings.CutSuffix(s, " ms"); ok {
+		n, err := strconv.ParseInt(ms, 10, 64)
+		return time.Duration(n) * time.Millisecond, err
+	}
+	var total time.Duration
+	for _, part := range strings.Fields(s) {
+		if days, ok := strings.CutSuffix(part, "d"); ok {
+			n, err := strconv.ParseInt(days, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			total += time.Duration(n) * 24 * time.Hour
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			return nil, err
+		}
+		total += d
+	}
+	return total, nil
+}
+
+// timeLayoutInverse parses a formatted time with layout.
+func timeLayoutInverse(layout string) InverseFunc {
+	return func(data []byte, _ reflect.Type) (any, error) {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, err
+		}
+		return time.Parse(layout, s)
+	}
+}
+
+// unixTimeInverse parses the Unix timestamps of time_unix, time_unix_ms and time_unix_ns.
+func unixTimeInverse(name string) InverseFunc {
+	return func(data []byte, _ reflect.Type) (any, error) {
+		var n int64
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, err
+		}
+		switch name {
+		case "time_unix_ms":
+			return time.UnixMilli(n), nil
+		case "time_unix_ns":
+			return time.Unix(0, n), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+}
@@ -0,0 +1,330 @@
+package slog
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ----- W3C Trace Context -----
+
+// Header names defined by the W3C Trace Context recommendation.
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// TraceFlagsKey is the field name of the trace flags emitted with trace_id and span_id.
+const TraceFlagsKey = "trace_flags"
+
+// ParentSpanIDKey is the field name of the parent span ID of a child span context.
+const ParentSpanIDKey = "parent_span_id"
+
+const (
+	traceparentLen     = 55 // "00-" + 32 + "-" + 16 + "-" + 2
+	maxTracestateItems = 32
+)
+
+// ErrInvalidTraceparent is returned for malformed traceparent headers.
+var ErrInvalidTraceparent = errors.New("log: invalid traceparent")
+
+// TraceID is a 16-byte W3C trace ID.
+type TraceID [16]byte
+
+// IsValid reports whether the ID is not all zeros.
+func (t TraceID) IsValid() bool { return t != TraceID{} }
+
+func (t TraceID) String() string { return hex.EncodeToString(t[:]) }
+
+// SpanID is an 8-byte W3C parent/span ID.
+type SpanID [8]byte
+
+// IsValid reports whether the ID is not all zeros.
+func (s SpanID) IsValid() bool { return s != SpanID{} }
+
+func (s SpanID) String() string { return hex.EncodeToString(s[:]) }
+
+// TraceFlags holds the trace-flags byte of a traceparent.
+type TraceFlags byte
+
+// FlagSampled marks a trace as sampled by the caller.
+const FlagSampled TraceFlags = 0x01
+
+// Sampled reports whether the sampled flag is set.
+func (f TraceFlags) Sampled() bool { return f&FlagSampled != 0 }
+
+func (f TraceFlags) String() string { return hex.EncodeToString([]byte{byte(f)}) }
+
+// TraceStateMember is one key=value entry of a tracestate header.
+type TraceStateMember struct {
+	Key   string
+	Value string
+}
+
+// TraceState is a parsed tracestate header, most recently updated vendor first.
+type TraceState []TraceStateMember
+
+// String renders the tracestate header value.
+func (ts TraceState) String() string {
+	parts := make([]string, len(ts))
+	for i, m := range ts {
+		parts[i] = m.Key + "=" + m.Value
+	}
+	return strings.Join(parts, ",")
+}
+
+// Get returns the value of key.
+func (ts TraceState) Get(key string) (string, bool) {
+	for _, m := range ts {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set returns a copy with key moved to the front and set to value, as required
+// when a vendor updates its entry.
+func (ts TraceState) Set(key, value string) (TraceState, error) {
+	if !validTracestateKey(key) || !validTracestateValue(value) {
+		return ts, fmt.Errorf("log: invalid tracestate member %q", key+"="+value)
+	}
+	out := TraceState{{Key: key, Value: value}}
+	for _, m := range ts {
+		if m.Key != key && len(out) < maxTracestateItems {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// SpanContext identifies the current span of a distributed trace.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   TraceFlags
+	State   TraceState
+
+	// ParentSpanID is the span this one was created from by NewChild, zero otherwise.
+	// It is logged but not propagated.
+	ParentSpanID SpanID
+}
+
+// IsValid reports whether both IDs are set.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent renders the version 00 traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + sc.Flags.String()
+}
+
+// NewChild returns a span context in the same trace with a new span ID, whose
+// parent is sc.
+func (sc SpanContext) NewChild() SpanContext {
+	child := sc
+	child.SpanID = newSpanID()
+	child.ParentSpanID = sc.SpanID
+	return child
+}
+
+// Fields returns the trace_id, span_id and trace_flags fields of the span context,
+// and parent_span_id for child spans.
+func (sc SpanContext) Fields() []Field {
+	fields := []Field{
+		{Key: TraceIDKey, Value: sc.TraceID.String()},
+		{Key: SpanIDKey, Value: sc.SpanID.String()},
+		{Key: TraceFlagsKey, Value: sc.Flags.String()},
+	}
+	if sc.ParentSpanID.IsValid() {
+		fields = append(fields, Field{Key: ParentSpanIDKey, Value: sc.ParentSpanID.String()})
+	}
+	return fields
+}
+
+// NewSpanContext starts a new trace with random IDs.
+func NewSpanContext(sampled bool) SpanContext {
+	sc := SpanContext{SpanID: newSpanID()}
+	for !sc.TraceID.IsValid() {
+		_, _ = rand.Read(sc.TraceID[:])
+	}
+	if sampled {
+		sc.Flags = FlagSampled
+	}
+	return sc
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	for !id.IsValid() {
+		_, _ = rand.Read(id[:])
+	}
+	return id
+}
+
+// ParseTraceparent parses a traceparent header. Versions above 00 are accepted
+// as long as they start with the version 00 fields.
+func ParseTraceparent(h string) (SpanContext, error) {
+	var sc SpanContext
+	h = strings.TrimSpace(h)
+	if len(h) < traceparentLen || (len(h) > traceparentLen && h[traceparentLen] != '-') {
+		return sc, ErrInvalidTraceparent
+	}
+	if h[2] != '-' || h[35] != '-' || h[52] != '-' {
+		return sc, ErrInvalidTraceparent
+	}
+	version, ok := decodeLowerHex(h[0:2], 1)
+	if !ok || version[0] == 0xff || (version[0] == 0 && len(h) != traceparentLen) {
+		return sc, ErrInvalidTraceparent
+	}
+	traceID, ok1 := decodeLowerHex(h[3:35], 16)
+	spanID, ok2 := decodeLowerHex(h[36:52], 8)
+	flags, ok3 := decodeLowerHex(h[53:55], 1)
+	if !ok1 || !ok2 || !ok3 {
+		return sc, ErrInvalidTraceparent
+	}
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Flags = TraceFlags(flags[0])
+	if !sc.IsValid() {
+		return SpanContext{}, ErrInvalidTraceparent
+	}
+	return sc, nil
+}
+
+// decodeLowerHex decodes s into n bytes, rejecting upper-case digits as the spec requires.
+func decodeLowerHex(s string, n int) ([]byte, bool) {
+	if len(s) != 2*n || strings.ToLower(s) != s {
+		return nil, false
+	}
+	b, err := hex.DecodeString(s)
+	return b, err == nil
+}
+
+// ParseTracestate parses a tracestate header. Empty members are skipped; any
+// invalid or duplicate member invalidates the whole header.
+func ParseTracestate(h string) (TraceState, error) {
+	var ts TraceState
+	seen := make(map[string]bool)
+	for _, member := range strings.Split(h, ",") {
+		member = strings.Trim(member, " \t")
+		if member == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(member, "=")
+		if !ok || !validTracestateKey(key) || !validTracestateValue(value) || seen[key] {
+			return nil, fmt.Errorf("log: invalid tracestate member %q", member)
+		}
+		seen[key] = true
+		ts = append(ts, TraceStateMember{Key: key, Value: value})
+	}
+	if len(ts) > maxTracestateItems {
+		return nil, fmt.Errorf("log: tracestate has %d members, max %d", len(ts), maxTracestateItems)
+	}
+	return ts, nil
+}
+
+// validTracestateKey checks simple keys and multi-tenant "tenant@system" keys.
+func validTracestateKey(key string) bool {
+	tenant, system, multi := strings.Cut(key, "@")
+	if !multi {
+		return validKeyPart(key, 256, false)
+	}
+	return validKeyPart(tenant, 241, true) && validKeyPart(system, 14, false)
+}
+
+func validKeyPart(s string, max int, digitFirst bool) bool {
+	if s == "" || len(s) > max {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9':
+			if i == 0 && !digitFirst {
+				return false
+			}
+		case i > 0 && (c == '_' || c == '-' || c == '*' || c == '/'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func validTracestateValue(v string) bool {
+	if v == "" || len(v) > 256 || v[len(v)-1] == ' ' {
+		return false
+	}
+	for i := 0; i < len(v); i++ {
+		if c := v[i]; c < 0x20 || c > 0x7e || c == ',' || c == '=' {
+			return false
+		}
+	}
+	return true
+}
+
+// ContextWithSpanContext attaches sc to ctx. MarshalWithContext and ContextFields
+// then emit trace_id, span_id and trace_flags.
+func ContextWithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanCtxKey, sc)
+}
+
+// SpanContextFromContext returns the span context attached to ctx.
+func SpanContextFromContext(ctx context.Context) (SpanContext, bool) {
+	if ctx == nil {
+		return SpanContext{}, false
+	}
+	sc, ok := ctx.Value(spanCtxKey).(SpanContext)
+	return sc, ok && sc.IsValid()
+}
+
+// SpanContextFromHeaders extracts a span context from traceparent/tracestate
+// headers. A malformed tracestate is dropped without rejecting the traceparent.
+func SpanContextFromHeaders(h http.Header) (SpanContext, error) {
+	sc, err := ParseTraceparent(h.Get(TraceparentHeader))
+	if err != nil {
+		return sc, err
+	}
+	if values := h.Values(TracestateHeader); len(values) > 0 {
+		if ts, err := ParseTracestate(strings.Join(values, ",")); err == nil {
+			sc.State = ts
+		}
+	}
+	return sc, nil
+}
+
+// InjectTraceHeaders sets the traceparent and tracestate headers for an outgoing request.
+func InjectTraceHeaders(h http.Header, sc SpanContext) {
+	if !sc.IsValid() {
+		return
+	}
+	h.Set(TraceparentHeader, sc.Traceparent())
+	if len(sc.State) > 0 {
+		h.Set(TracestateHeader, sc.State.String())
+	} else {
+		h.Del(TracestateHeader)
+	}
+}
+
+// TraceMiddleware attaches a span context to each request's context. Requests
+// carrying a valid traceparent continue that trace in a new child span, whose
+// records carry the caller's span as parent_span_id; others start a new,
+// unsampled trace.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sc, err := SpanContextFromHeaders(r.Header)
+		if err != nil {
+			sc = NewSpanContext(false)
+		} else {
+			sc = sc.NewChild()
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithSpanContext(r.Context(), sc)))
+	})
+}
@@ -0,0 +1,480 @@
+package slog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ----- Unmarshal -----
+
+// Masked holds the logged form of a masked value, e.g. "a***@example.com".
+// Unmarshal stores masked values in fields of this type and leaves masked
+// fields of any other type at their zero value.
+type Masked string
+
+var (
+	maskedType      = reflect.TypeOf(Masked(""))
+	unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// InverseFunc parses the JSON output of a serializer back into a value of type
+// t, or of a type convertible to t.
+type InverseFunc func(data []byte, t reflect.Type) (any, error)
+
+var serInverses sync.Map // serializer name → InverseFunc
+
+// RegisterSerializerInverse registers the inverse of a serializer, used by
+// Unmarshal. Fields whose serializer has no inverse are left zero.
+func RegisterSerializerInverse(name string, fn InverseFunc) {
+	if name == "" || fn == nil {
+		return
+	}
+	serInverses.Store(name, fn)
+}
+
+// RegisterSerializerWithInverse registers a serializer together with its inverse.
+func RegisterSerializerWithInverse(name string, fn SerializerFunc, inverse InverseFunc) {
+	RegisterSerializer(name, fn)
+	RegisterSerializerInverse(name, inverse)
+}
+
+func getInverse(name string) (InverseFunc, bool) {
+	v, ok := serInverses.Load(name)
+	if !ok {
+		return nil, false
+	}
+	return v.(InverseFunc), true
+}
+
+// UnmarshalError reports a value that could not be decoded.
+type UnmarshalError struct {
+	Type  reflect.Type
+	Field string
+	Path  string // dotted path of the failing value; empty at the root
+	Err   error
+
+	segs []string // path segments, kept for Pointer since keys may contain "." or "["
+}
+
+func (e *UnmarshalError) Error() string {
+	at := ""
+	if e.Path != "" {
+		at = " at " + e.Path
+	}
+	if e.Field != "" {
+		return fmt.Sprintf("log: unmarshal field %s of type %s%s: %v", e.Field, e.Type, at, e.Err)
+	}
+	return fmt.Sprintf("log: unmarshal type %s%s: %v", e.Type, at, e.Err)
+}
+
+// Pointer returns Path as a JSON Pointer fragment, e.g. "#/items/3/price".
+func (e *UnmarshalError) Pointer() string {
+	if e.segs == nil {
+		return jsonPointer(splitPattern(e.Path))
+	}
+	return jsonPointer(e.segs)
+}
+
+func (e *UnmarshalError) Unwrap() error {
+	return e.Err
+}
+
+// Unmarshal parses the JSON output of Marshal into v, which must be a non-nil pointer.
+//
+// It is the inverse of Marshal as far as the output allows: keys are matched
+// by slog tag name, inline fields are filled from the enclosing object, and
+// serializers are reversed through their registered inverse (the built-in
+// time, duration and currency serializers have one). Scalar values written
+// with the string option are parsed back into their field type; other fields
+// with the string option are left zero. Masked fields are left zero unless
+// they have type Masked. Fields hidden by level, dropped by class policy,
+// replaced by an error fallback or produced by MarshalLog (for types without
+// json.Unmarshaler) keep their zero value. Unknown keys are ignored.
+func Unmarshal(data []byte, v any) error {
+	return UnmarshalWithOpts(data, v)
+}
+
+// UnmarshalWithOpts is Unmarshal for output produced with opts, so that class
+// policies and MaskSensitive are known.
+func UnmarshalWithOpts(data []byte, v any, opts ...Option) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("log: Unmarshal expects a non-nil pointer, got %T", v)
+	}
+
+	enc := newEncoder()
+	defer releaseEncoder(enc)
+	enc.opts = newOptions(opts)
+	enc.state = newEncodeState(enc.opts)
+	if err := enc.loadPolicy(); err != nil {
+		return &UnmarshalError{Type: rv.Elem().Type(), Err: err}
+	}
+
+	d := &decoder{e: enc}
+	if err := d.decode(data, rv.Elem(), nil); err != nil {
+		if _, ok := err.(*UnmarshalError); ok {
+			return err
+		}
+		return &UnmarshalError{Type: rv.Elem().Type(), Err: err}
+	}
+	return nil
+}
+
+// decoder mirrors the encoder, reusing its struct info and class policy.
+type decoder struct {
+	e *encoder
+}
+
+func isNull(data []byte) bool {
+	return bytes.Equal(bytes.TrimSpace(data), []byte("null"))
+}
+
+// decode stores data in the addressable value rv.
+func (d *decoder) decode(data []byte, rv reflect.Value, path []string) error {
+	if isNull(data) {
+		rv.SetZero()
+		return nil
+	}
+
+	t := rv.Type()
+	switch t.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			rv.Set(reflect.New(t.Elem()))
+		}
+		return d.decode(data, rv.Elem(), path)
+	case reflect.Struct:
+		return d.decodeStruct(data, rv, path)
+	case reflect.Slice, reflect.Array:
+		return d.decodeList(data, rv, path)
+	case reflect.Map:
+		return d.decodeMap(data, rv, path)
+	case reflect.Interface:
+		if t.NumMethod() > 0 {
+			return nil // errors and other interfaces cannot be rebuilt
+		}
+	}
+	return json.Unmarshal(data, rv.Addr().Interface())
+}
+
+func (d *decoder) decodeStruct(data []byte, rv reflect.Value, path []string) error {
+	t := rv.Type()
+	pt := reflect.PointerTo(t)
+	if !d.e.opts.DisableLoggerInterface && (t.Implements(loggerType) || pt.Implements(loggerType)) {
+		if pt.Implements(unmarshalerType) {
+			return json.Unmarshal(data, rv.Addr().Interface())
+		}
+		return nil
+	}
+
+	info := d.e.getStructInfo(t)
+	if !info.hasLogTag {
+		if d.e.opts.DisableJSONFallback {
+			return nil
+		}
+		if pt.Implements(unmarshalerType) {
+			return json.Unmarshal(data, rv.Addr().Interface())
+		}
+	}
+
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	return d.decodeFields(obj, rv, info, path)
+}
+
+// decodeFields fills the fields of struct rv from obj. Inline fields read from the same object.
+func (d *decoder) decodeFields(obj map[string]json.RawMessage, rv reflect.Value, info *structInfo, path []string) error {
+	t := rv.Type()
+	policy := d.e.policy()
+	if !info.hasLogTag {
+		for _, fi := range info.fields {
+			raw, ok := obj[fi.jsonName]
+			if fi.jsonName == "" || fi.jsonName == "-" || !ok {
+				continue
+			}
+			goName := t.Field(fi.index).Name
+			fieldPath := append(path, fi.jsonName)
+			if err := d.decodeEntry(raw, rv.Field(fi.index), policy, t, goName, fi.jsonName, path); err != nil {
+				return d.fieldError(t, goName, fieldPath, err)
+			}
+		}
+		return nil
+	}
+
+	for _, fi := range info.fields {
+		if policy != nil && fi.opts.Name != "-" {
+			var keep bool
+			if fi, keep = applyPolicyAt(policy, path, t, t.Field(fi.index).Name, fi); !keep {
+				continue
+			}
+		}
+		if fi.opts.Name == "-" || d.e.classAction(fi.opts.Class) == ClassDrop {
+			continue
+		}
+		fv := rv.Field(fi.index)
+
+		if fi.opts.Inline && fv.Kind() == reflect.Struct && !d.e.classMasked(fi) {
+			if err := d.decodeFields(obj, fv, d.e.getStructInfo(fv.Type()), path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := obj[fi.opts.Name]
+		if fi.opts.Name == "" || !ok {
+			continue
+		}
+		fieldPath := append(path, fi.opts.Name)
+		if err := d.decodeField(raw, fv, fi, fieldPath); err != nil {
+			return d.fieldError(t, t.Field(fi.index).Name, fieldPath, err)
+		}
+	}
+	return nil
+}
+
+// decodeEntry decodes a json-tagged field or map entry name of the object at
+// path. Like the encoder, it routes entries matched by a policy rule through
+// decodeField and leaves values masked by MaskSensitive zero.
+func (d *decoder) decodeEntry(raw []byte, fv reflect.Value, policy *RedactionPolicy, owner reflect.Type, goName, name string, path []string) error {
+	entryPath := append(path, name)
+	if policy != nil {
+		plain := fieldOptions{Name: name}
+		fi, keep := applyPolicyAt(policy, path, owner, goName, fieldInfo{opts: plain})
+		if !keep {
+			return nil
+		}
+		if fi.opts != plain {
+			return d.decodeField(raw, fv, fi, entryPath)
+		}
+	}
+	if d.sensitiveKey(name, fv.Type()) {
+		return setMasked(raw, fv)
+	}
+	return d.decode(raw, fv, entryPath)
+}
+
+// sensitiveKey reports whether MaskSensitive masked a value of type t because of its key name.
+func (d *decoder) sensitiveKey(name string, t reflect.Type) bool {
+	if !d.e.opts.MaskSensitive || name == "" {
+		return false
+	}
+	return isScalarKind(derefType(t).Kind()) && d.e.sensitive().IsSensitiveKey(name)
+}
+
+// isScalarKind reports whether values of kind k are written as JSON strings, numbers or bools.
+func isScalarKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.String, reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// decodeField mirrors encodeField: class masks, serializers, field masks, then the string option.
+func (d *decoder) decodeField(raw []byte, fv reflect.Value, fi fieldInfo, path []string) error {
+	if isErrorFallback(raw) {
+		return nil
+	}
+
+	switch {
+	case d.e.classMasked(fi):
+		return setMasked(raw, fv)
+	case fi.opts.Serializer != "":
+		return d.invert(fi.opts.Serializer, raw, fv)
+	case d.e.classAction(fi.opts.Class) == ClassKeep:
+		// Exposed by policy, not masked
+	case fi.opts.Mask == "encrypt" && isScalarKind(derefType(fv.Type()).Kind()):
+		return d.decodeEncrypted(raw, fv, path)
+	case fi.opts.Mask != "" && isScalarKind(derefType(fv.Type()).Kind()):
+		return setMasked(raw, fv)
+	case d.e.opts.MaskSensitive && derefType(fv.Type()).Kind() == reflect.String:
+		return setMasked(raw, fv)
+	}
+
+	if fi.opts.String && !isScalarKind(derefType(fv.Type()).Kind()) {
+		return nil // the string form of maps, structs and lists cannot be parsed back
+	}
+	if fi.opts.String && derefType(fv.Type()).Kind() != reflect.String {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			raw = []byte(s)
+		}
+	}
+	return d.decode(raw, fv, path)
+}
+
+// decodeEncrypted decodes a field with the encrypt mask. Envelopes are handled
+// like other masks; values opened by DecryptFields hold the string form of the
+// field and are parsed back into its type.
+func (d *decoder) decodeEncrypted(raw []byte, fv reflect.Value, path []string) error {
+	var s string
+	if json.Unmarshal(raw, &s) != nil || IsEnvelope(s) {
+		return setMasked(raw, fv)
+	}
+	if derefType(fv.Type()).Kind() != reflect.String {
+		raw = []byte(s)
+	}
+	return d.decode(raw, fv, path)
+}
+
+// invert applies the inverse of serializer name. Serializers without one leave the field zero.
+func (d *decoder) invert(name string, raw []byte, fv reflect.Value) error {
+	inverse, ok := getInverse(name)
+	if !ok {
+		return nil
+	}
+	if isNull(raw) {
+		fv.SetZero()
+		return nil
+	}
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	v, err := inverse(raw, fv.Type())
+	if err != nil {
+		return fmt.Errorf("serializer %q inverse: %w", name, err)
+	}
+	return setValue(fv, v)
+}
+
+func (d *decoder) decodeList(data []byte, rv reflect.Value, path []string) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return err
+	}
+	if rv.Kind() == reflect.Slice {
+		rv.Set(reflect.MakeSlice(rv.Type(), len(items), len(items)))
+	} else {
+		rv.SetZero()
+	}
+	// Items are masked by the name of the field or key holding the list
+	masked := d.sensitiveKey(nearestName(path), rv.Type().Elem())
+	for i := 0; i < len(items) && i < rv.Len(); i++ {
+		if masked {
+			if err := setMasked(items[i], rv.Index(i)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := d.decode(items[i], rv.Index(i), append(path, "["+strconv.Itoa(i)+"]")); err != nil {
+			return d.fieldError(rv.Type(), "", append(path, "["+strconv.Itoa(i)+"]"), err)
+		}
+	}
+	return nil
+}
+
+// decodeMap decodes objects into maps with string keys, the only keys Marshal emits.
+func (d *decoder) decodeMap(data []byte, rv reflect.Value, path []string) error {
+	t := rv.Type()
+	if t.Key().Kind() != reflect.String {
+		return nil
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	m := reflect.MakeMapWithSize(t, len(obj))
+	policy := d.e.policy()
+	for k, raw := range obj {
+		elem := reflect.New(t.Elem()).Elem()
+		if err := d.decodeEntry(raw, elem, policy, nil, "", k, path); err != nil {
+			return d.fieldError(t, "", append(path, k), err)
+		}
+		m.SetMapIndex(reflect.ValueOf(k).Convert(t.Key()), elem)
+	}
+	rv.Set(m)
+	return nil
+}
+
+// nearestName returns the last path segment that is not a list index.
+func nearestName(path []string) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		if !strings.HasPrefix(path[i], "[") {
+			return path[i]
+		}
+	}
+	return ""
+}
+
+// fieldError wraps err with the path of the failing value, keeping deeper paths.
+func (d *decoder) fieldError(t reflect.Type, field string, path []string, err error) error {
+	if ue, ok := err.(*UnmarshalError); ok && ue.Path != "" {
+		return ue
+	}
+	segs := append([]string(nil), path...)
+	return &UnmarshalError{Type: t, Field: field, Path: pathString(segs), Err: err, segs: segs}
+}
+
+// isErrorFallback reports whether raw is the {"_error":{...}} object of a failed field.
+func isErrorFallback(raw []byte) bool {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) != nil || len(obj) != 1 {
+		return false
+	}
+	_, ok := obj[errorKey]
+	return ok
+}
+
+// setMasked stores a masked value in fv if it is a Masked (or *Masked) field.
+func setMasked(raw []byte, fv reflect.Value) error {
+	if derefType(fv.Type()) != maskedType {
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil // masked composites are omitted, anything else is not a mask
+	}
+	for fv.Kind() == reflect.Ptr {
+		if fv.IsNil() {
+			fv.Set(reflect.New(fv.Type().Elem()))
+		}
+		fv = fv.Elem()
+	}
+	fv.SetString(s)
+	return nil
+}
+
+// setValue stores v in rv, converting between compatible types (float64 to int64, ...).
+// Floats are only stored in integer types if they are whole and in range.
+func setValue(rv reflect.Value, v any) error {
+	val := reflect.ValueOf(v)
+	switch {
+	case !val.IsValid():
+		rv.SetZero()
+	case val.CanFloat() && isIntegerKind(rv.Kind()):
+		f := val.Float()
+		if f != math.Trunc(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("cannot store %v in %s without losing precision", f, rv.Type())
+		}
+		if rv.CanInt() {
+			if f < math.MinInt64 || f >= math.MaxInt64 || rv.OverflowInt(int64(f)) {
+				return fmt.Errorf("%v overflows %s", f, rv.Type())
+			}
+			rv.SetInt(int64(f))
+		} else {
+			if f < 0 || f >= math.MaxUint64 || rv.OverflowUint(uint64(f)) {
+				return fmt.Errorf("%v overflows %s", f, rv.Type())
+			}
+			rv.SetUint(uint64(f))
+		}
+	case val.Type().AssignableTo(rv.Type()):
+		rv.Set(val)
+	case val.Type().ConvertibleTo(rv.Type()) && (rv.Kind() == reflect.String) == (val.Kind() == reflect.String):
+		rv.Set(val.Convert(rv.Type()))
+	default:
+		return fmt.Errorf("cannot store %s in %s", val.Type(), rv.Type())
+	}
+	return nil
+}